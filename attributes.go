@@ -0,0 +1,151 @@
+package sentinel
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Attribute names used by OData services mapped to OpenSearch ones, after normalization
+var attributeAliases = map[string]string{
+	// DHuS OData
+	"generationtime":                "generationdate",
+	"instrument":                    "instrumentname",
+	"instrumentabbreviation":        "instrumentshortname",
+	"missiondatatakeid":             "s2datatakeid",
+	"nssdcidentifier":               "platformidentifier",
+	"orbitnumberstart":              "orbitnumber",
+	"passdirection":                 "orbitdirection",
+	"relativeorbitstart":            "relativeorbitnumber",
+	"satellitename":                 "platformname",
+	"sensingstart":                  "beginposition",
+	"sensingstop":                   "endposition",
+	"tileidentifier":                "tileid",
+	"tileidentifierhorizontalorder": "hv_order_tileid",
+	// CDSE OData
+	"beginningdatetime":       "beginposition",
+	"endingdatetime":          "endposition",
+	"cloudcover":              "cloudcoverpercentage",
+	"datastripid":             "datastripidentifier",
+	"operationalmode":         "sensoroperationalmode",
+	"platformshortname":       "platformname",
+	"productgroupid":          "s2datatakeid",
+	"sourceproductorigindate": "generationdate",
+}
+
+// NormalizeAttributeName converts attribute name used by OData services, e.g. "Cloud cover percentage"
+// or "cloudCover", to the name used by OpenSearch API, e.g. "cloudcoverpercentage"
+func NormalizeAttributeName(name string) string {
+	if name == "hv_order_tileid" {
+		return name
+	}
+	var sb strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	normalized := sb.String()
+	if alias, ok := attributeAliases[normalized]; ok {
+		return alias
+	}
+	return normalized
+}
+
+// SetAttribute sets entry field corresponding to OpenSearch attribute name. All attributes,
+// known or not, are kept in Attributes
+func (qer *QueryEntryResponse) SetAttribute(name string, value string) error {
+	var err error
+	if qer.Attributes == nil {
+		qer.Attributes = make(map[string]string)
+	}
+	qer.Attributes[name] = value
+
+	switch name {
+	case "sensoroperationalmode":
+		qer.SensorOperationalMode = value
+	case "gmlfootprint":
+		qer.GMLFootprint = value
+	case "footprint":
+		qer.Footprint = value
+	case "tileid":
+		qer.TileId = value
+	case "hv_order_tileid":
+		qer.HVOrderTileid = value
+	case "format":
+		qer.Format = value
+	case "processingbaseline":
+		qer.ProcessingBaseline = value
+	case "platformname":
+		qer.PlatformName = value
+	case "filename":
+		qer.FileName = value
+	case "instrumentname":
+		qer.InstrumentName = value
+	case "instrumentshortname":
+		qer.InstrumentShortName = value
+	case "size":
+		qer.Size = value
+	case "s2datatakeid":
+		qer.S2DataTakeID = value
+	case "producttype":
+		qer.ProductType = value
+	case "platformidentifier":
+		qer.PlatformIdentifier = value
+	case "level1cpdiidentifier":
+		qer.Level1CPDIdentifier = value
+	case "orbitdirection":
+		qer.OrbitDirection = value
+	case "platformserialidentifier":
+		qer.PlatformSerialIdentifier = value
+	case "processinglevel":
+		qer.ProcessingLevel = value
+	case "datastripidentifier":
+		qer.DataStripIdentifier = value
+	case "granuleidentifier":
+		qer.GranuleIdentifier = value
+	case "identifier":
+		qer.Identifier = value
+	case "uuid":
+		qer.UUID = value
+
+	case "orbitnumber":
+		qer.OrbitNumber, _ = strconv.Atoi(value)
+	case "relativeorbitnumber":
+		qer.RelativeOrbitNumber, _ = strconv.Atoi(value)
+
+	case "cloudcoverpercentage":
+		qer.CloudCoverPercentage, _ = strconv.ParseFloat(value, 64)
+	case "illuminationazimuthangle":
+		qer.IlluminationAzimuthAngle, _ = strconv.ParseFloat(value, 64)
+	case "illuminationzenithangle":
+		qer.IlluminationZenithAngle, _ = strconv.ParseFloat(value, 64)
+	case "vegetationpercentage":
+		qer.VegetationPercentage, _ = strconv.ParseFloat(value, 64)
+	case "notvegetatedpercentage":
+		qer.NotVegetatedPercentage, _ = strconv.ParseFloat(value, 64)
+	case "waterpercentage":
+		qer.WaterPercentage, _ = strconv.ParseFloat(value, 64)
+	case "unclassifiedpercentage":
+		qer.UnclassifiedPercentage, _ = strconv.ParseFloat(value, 64)
+	case "mediumprobacloudspercentage":
+		qer.MediumProbaCloudsPercentage, _ = strconv.ParseFloat(value, 64)
+	case "highprobacloudspercentage":
+		qer.HighProbaCloudsPercentage, _ = strconv.ParseFloat(value, 64)
+	case "snowicepercentage":
+		qer.SnowIcePercentage, _ = strconv.ParseFloat(value, 64)
+
+	case "datatakesensingstart":
+		qer.DataTakeSensingStart, err = time.Parse(time.RFC3339, value)
+	case "generationdate":
+		qer.GenerationDate, err = time.Parse(time.RFC3339, value)
+	case "beginposition":
+		qer.BeginPosition, err = time.Parse(time.RFC3339, value)
+	case "endposition":
+		qer.EndPosition, err = time.Parse(time.RFC3339, value)
+	case "ingestiondate":
+		qer.IngestionDate, err = time.Parse(time.RFC3339, value)
+	}
+	return err
+}
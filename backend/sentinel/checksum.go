@@ -0,0 +1,166 @@
+package sentinel_engine
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/sha3"
+)
+
+// Checksum is a product checksum published by the hub
+type Checksum = sentinel.Checksum
+
+const (
+	AlgorithmMD5     = "MD5"
+	AlgorithmSHA256  = "SHA256"
+	AlgorithmSHA3256 = "SHA3-256"
+	AlgorithmBLAKE3  = "BLAKE3"
+)
+
+var (
+	hashMu           sync.RWMutex
+	hashConstructors = map[string]func() hash.Hash{
+		AlgorithmMD5:     md5.New,
+		AlgorithmSHA256:  sha256.New,
+		AlgorithmSHA3256: func() hash.Hash { return sha3.New256() },
+		AlgorithmBLAKE3:  func() hash.Hash { return blake3.New() },
+	}
+	// Preferred algorithms when product has several checksums, strongest first
+	hashPreference = []string{AlgorithmBLAKE3, AlgorithmSHA3256, AlgorithmSHA256, AlgorithmMD5}
+)
+
+// RegisterHash adds support of checksum algorithm published by the hub. Registered
+// algorithms are used only if product has no checksum of built-in ones.
+func RegisterHash(algorithm string, newHash func() hash.Hash) {
+	hashMu.Lock()
+	defer hashMu.Unlock()
+	algorithm = normalizeAlgorithm(algorithm)
+	if _, ok := hashConstructors[algorithm]; !ok {
+		hashPreference = append(hashPreference, algorithm)
+	}
+	hashConstructors[algorithm] = newHash
+}
+
+func normalizeAlgorithm(algorithm string) string {
+	algorithm = strings.ToUpper(strings.TrimSpace(algorithm))
+	switch strings.NewReplacer("-", "", "_", "").Replace(algorithm) {
+	case "SHA256":
+		return AlgorithmSHA256
+	case "SHA3256":
+		return AlgorithmSHA3256
+	}
+	return algorithm
+}
+
+// selectHash returns hash of preferred supported algorithm and expected checksum for it
+func selectHash(checksums []Checksum) (hash.Hash, Checksum, bool) {
+	hashMu.RLock()
+	defer hashMu.RUnlock()
+	for _, alg := range hashPreference {
+		for _, c := range checksums {
+			if normalizeAlgorithm(c.Algorithm) == alg && c.Value != "" {
+				return hashConstructors[alg](), c, true
+			}
+		}
+	}
+	return nil, Checksum{}, false
+}
+
+func checkSum(productID string, h hash.Hash, expected Checksum) error {
+	actual := fmt.Sprintf("%x", h.Sum(nil))
+	if !strings.EqualFold(actual, strings.TrimSpace(expected.Value)) {
+		return ErrIntegrityError{
+			productID: productID,
+			algorithm: normalizeAlgorithm(expected.Algorithm),
+			expected:  expected.Value,
+			actual:    actual,
+		}
+	}
+	return nil
+}
+
+func unpackChecksums(bs []byte) (res []Checksum, err error) {
+	if len(bs) == 0 {
+		return res, nil
+	}
+	switch bs[0] {
+	case '{':
+		var tempRes Checksum
+		err = json.Unmarshal(bs, &tempRes)
+		return []Checksum{tempRes}, err
+	case '[':
+		err = json.Unmarshal(bs, &res)
+		return
+	}
+	return
+}
+
+// Checksums returns product checksums from OData metadata
+func (se SentinelEngine) Checksums(productID string) ([]Checksum, error) {
+	var product struct {
+		Checksum json.RawMessage `json:"Checksum"`
+	}
+	if err := se.getJSON(se.getProductURL(productID), &product); err != nil {
+		return nil, fmt.Errorf("error on get product metadata: %w", err)
+	}
+	checksums, err := unpackChecksums(product.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("error on parse checksum: %s", err)
+	}
+	return checksums, nil
+}
+
+// Verify checks integrity of already downloaded product file against checksum published by the hub
+func (se SentinelEngine) Verify(productID string, filePath string) error {
+	checksums, err := se.Checksums(productID)
+	if err != nil {
+		return err
+	}
+	return se.reportChecksum(VerifyFile(productID, filePath, checksums))
+}
+
+// reportChecksum reports checksum mismatch to metrics and returns err as is
+func (se SentinelEngine) reportChecksum(err error) error {
+	var ie ErrIntegrityError
+	if errors.As(err, &ie) {
+		se.obs.ChecksumFailure(ie.algorithm)
+	}
+	return err
+}
+
+func unsupportedChecksum(productID string, checksums []Checksum) ErrUnsupportedChecksum {
+	algorithms := make([]string, len(checksums))
+	for i := range checksums {
+		algorithms[i] = checksums[i].Algorithm
+	}
+	return ErrUnsupportedChecksum{productID: productID, algorithms: algorithms}
+}
+
+// VerifyFile checks integrity of file against the strongest supported of given checksums
+func VerifyFile(productID string, filePath string, checksums []Checksum) error {
+	h, expected, ok := selectHash(checksums)
+	if !ok {
+		return unsupportedChecksum(productID, checksums)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error on open file: %s", err)
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(h, f); err != nil {
+		return fmt.Errorf("error on read file: %s", err)
+	}
+	return checkSum(productID, h, expected)
+}
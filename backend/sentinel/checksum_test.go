@@ -0,0 +1,129 @@
+package sentinel_engine
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var testContent = []byte("S2A_MSIL2A product content")
+
+func newTestHub(t *testing.T, metadata string, etag string, opts ...Option) SentinelEngine {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/odata/v1/Products('id')":
+			if metadata == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, metadata)
+		case "/odata/v1/Products('id')/$value":
+			w.Header().Set("Content-Disposition", `attachment; filename="product.zip"`)
+			w.Header().Set("Content-Length", fmt.Sprint(len(testContent)))
+			w.Header().Set("Etag", etag)
+			w.Write(testContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewSentinelEngine("user", "password", 0, append(opts, WithBaseURL(srv.URL))...)
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	md5Sum := fmt.Sprintf("%x", md5.Sum(testContent))
+	sha3Sum := fmt.Sprintf("%x", sha3.Sum256(testContent))
+
+	cases := []struct {
+		name      string
+		metadata  string
+		etag      string
+		integrity bool
+	}{
+		{"dhus", fmt.Sprintf(`{"d":{"Checksum":{"Algorithm":"MD5","Value":"%s"}}}`, md5Sum), "", false},
+		{"cdse", fmt.Sprintf(`{"Checksum":[{"Algorithm":"MD5","Value":"bad"},{"Algorithm":"SHA3-256","Value":"%s"}]}`, sha3Sum), "", false},
+		{"etag", "", `"` + md5Sum + `"`, false},
+		{"mismatch", `{"d":{"Checksum":{"Algorithm":"MD5","Value":"bad"}}}`, md5Sum, true},
+		{"unverified", "", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			se := newTestHub(t, c.metadata, c.etag)
+			dst := t.TempDir()
+			filePath, err := se.Download("id", dst)
+			if c.integrity {
+				var ie ErrIntegrityError
+				if !errors.As(err, &ie) {
+					t.Fatalf("error should be ErrIntegrityError, but is %v", err)
+				}
+				if files, _ := os.ReadDir(dst); filePath != "" || len(files) != 0 {
+					t.Errorf("corrupted file should be removed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error should be nil, but is %s", err)
+			}
+			if filePath != filepath.Join(dst, "product.zip") {
+				t.Errorf("unexpected file path %s", filePath)
+			}
+		})
+	}
+
+	se := newTestHub(t, "", "", WithRequireChecksum())
+	dst := t.TempDir()
+	var ue ErrUnverified
+	if filePath, err := se.Download("id", dst); !errors.As(err, &ue) || filePath != "" {
+		t.Errorf("error should be ErrUnverified, but is %v", err)
+	}
+	if files, _ := os.ReadDir(dst); len(files) != 0 {
+		t.Errorf("unverified file should be removed")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	se := newTestHub(t, `{"Checksum":[{"Algorithm":"CRC32","Value":"1"}]}`, "")
+	filePath := filepath.Join(t.TempDir(), "product.zip")
+	if err := os.WriteFile(filePath, testContent, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var ue ErrUnsupportedChecksum
+	if err := se.Verify("id", filePath); !errors.As(err, &ue) {
+		t.Errorf("error should be ErrUnsupportedChecksum, but is %v", err)
+	}
+	if err := VerifyFile("id", filePath, []Checksum{{Algorithm: "sha3_256", Value: fmt.Sprintf("%X", sha3.Sum256(testContent))}}); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+}
+
+func TestDownloadFileName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/odata/v1/Products('id')/$value" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="../../product.zip"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(testContent)))
+		w.Header().Set("Etag", fmt.Sprintf("%x", md5.Sum(testContent)))
+		w.Write(testContent)
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	dst := t.TempDir()
+	filePath, err := se.Download("id", dst)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if filePath != filepath.Join(dst, "____product.zip") {
+		t.Errorf("file name should be sanitized, but is %s", filePath)
+	}
+}
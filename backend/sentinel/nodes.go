@@ -0,0 +1,187 @@
+package sentinel_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+// Node is a file or directory of remote product, e.g. S2A_MSIL2A_...SAFE/GRANULE
+type Node struct {
+	Name           string
+	Path           string // slash separated path from product root, including root SAFE node
+	ContentLength  int64
+	ChildrenNumber int
+}
+
+func (n Node) IsDir() bool {
+	return n.ChildrenNumber > 0
+}
+
+type odataNode struct {
+	Name           string          `json:"Name"`
+	ContentLength  json.RawMessage `json:"ContentLength"`
+	ChildrenNumber json.RawMessage `json:"ChildrenNumber"`
+}
+
+func (se SentinelEngine) getNodeURL(productID string, nodePath string, suffix string) string {
+	link := se.getProductURL(productID)
+	for _, name := range strings.Split(nodePath, "/") {
+		if name == "" {
+			continue
+		}
+		link += fmt.Sprintf("/Nodes('%s')", strings.ReplaceAll(name, "'", "''"))
+	}
+	return link + "/" + suffix
+}
+
+// Nodes lists children of product node. Empty nodePath lists product root, which is usually a single SAFE directory
+func (se SentinelEngine) Nodes(productID string, nodePath string) ([]Node, error) {
+	var res struct {
+		Results []odataNode `json:"results"` // DHuS
+		Result  []odataNode `json:"result"`  // CDSE
+		Value   []odataNode `json:"value"`
+	}
+	if err := se.getJSON(se.getNodeURL(productID, nodePath, "Nodes"), &res); err != nil {
+		return nil, fmt.Errorf("error on list nodes of %s: %w", nodePath, err)
+	}
+
+	odataNodes := append(append(res.Results, res.Result...), res.Value...)
+	nodes := make([]Node, len(odataNodes))
+	for i, n := range odataNodes {
+		// names become local paths, so they must not add directories or escape product root
+		if n.Name == "" || n.Name == "." || strings.Contains(n.Name, "..") || strings.ContainsAny(n.Name, `/\`) {
+			return nil, fmt.Errorf("invalid name %q of node in %s", n.Name, nodePath)
+		}
+		nodes[i].Name = n.Name
+		nodes[i].Path = path.Join(nodePath, n.Name)
+		nodes[i].ContentLength, _ = strconv.ParseInt(rawString(n.ContentLength), 10, 64)
+		nodes[i].ChildrenNumber, _ = strconv.Atoi(rawString(n.ChildrenNumber))
+	}
+	return nodes, nil
+}
+
+// WalkNodes walks product tree depth-first calling fn for every node
+func (se SentinelEngine) WalkNodes(productID string, fn func(Node) error) error {
+	return se.walkNodes(productID, "", fn)
+}
+
+func (se SentinelEngine) walkNodes(productID string, nodePath string, fn func(Node) error) error {
+	nodes, err := se.Nodes(productID, nodePath)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err = fn(n); err != nil {
+			return err
+		}
+		if n.IsDir() {
+			if err = se.walkNodes(productID, n.Path, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MatchNode reports whether node matches any of patterns. Pattern containing slash is matched
+// against node path without root SAFE directory, e.g. GRANULE/*/IMG_DATA/R10m/*_B04_10m.jp2,
+// otherwise against node name, e.g. MTD_MSIL2A.xml
+func MatchNode(n Node, patterns ...string) bool {
+	relPath := n.Path
+	if i := strings.Index(relPath, "/"); i >= 0 {
+		relPath = relPath[i+1:]
+	}
+	for _, p := range patterns {
+		target := n.Name
+		if strings.Contains(p, "/") {
+			target = relPath
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadNodes downloads product files matching any of patterns (see MatchNode) into dst,
+// preserving SAFE directory layout. Returns local paths of downloaded files.
+func (se SentinelEngine) DownloadNodes(productID string, dst string, patterns ...string) ([]string, error) {
+	filePaths := make([]string, 0)
+	release, err := se.limiter.AcquireDownload(context.Background())
+	if err != nil {
+		return filePaths, err
+	}
+	defer release()
+	err = se.WalkNodes(productID, func(n Node) error {
+		if n.IsDir() || !MatchNode(n, patterns...) {
+			return nil
+		}
+		filePath := filepath.Join(dst, filepath.FromSlash(n.Path))
+		if err := se.downloadNode(productID, n, filePath); err != nil {
+			return err
+		}
+		filePaths = append(filePaths, filePath)
+		return nil
+	})
+	return filePaths, err
+}
+
+func (se SentinelEngine) downloadNode(productID string, n Node, filePath string) (err error) {
+	link := se.getNodeURL(productID, n.Path, "$value")
+	var status int
+	var n64 int64
+	ctx, op := se.obs.Start(context.Background(), telemetry.OpDownloadNode, link)
+	defer func() { op.End(status, n64, err) }()
+
+	req, err := se.newRequest(http.MethodGet, link)
+	if err != nil {
+		return fmt.Errorf("error on create request: %s", err)
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := se.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error on GET node %s: %s", n.Path, err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	if resp.StatusCode == http.StatusAccepted {
+		return ErrFileTriggered{productID: productID}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create local directory: %s", err)
+	}
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error on create local file: %s", err)
+	}
+
+	n64, err = io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return fmt.Errorf("error on saving node %s: %s", n.Path, err)
+	}
+	if n.ContentLength > 0 && n64 != n.ContentLength {
+		os.Remove(filePath)
+		return fmt.Errorf("node %s size mismatch: expected %d, got %d", n.Path, n.ContentLength, n64)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sentinel_engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDownloadNodes(t *testing.T) {
+	// node path -> children; files have no entry
+	tree := map[string][]string{
+		"":                                   {"P.SAFE"},
+		"P.SAFE":                             {"manifest.safe", "MTD_MSIL2A.xml", "GRANULE"},
+		"P.SAFE/GRANULE":                     {"L2A_T36UYA"},
+		"P.SAFE/GRANULE/L2A_T36UYA":          {"IMG_DATA"},
+		"P.SAFE/GRANULE/L2A_T36UYA/IMG_DATA": {"T36UYA_B04_10m.jp2", "T36UYA_B08_10m.jp2"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/odata/v1/Products('id')")
+		segments := make([]string, 0)
+		for _, s := range strings.Split(p, "/") {
+			if strings.HasPrefix(s, "Nodes('") {
+				segments = append(segments, strings.TrimSuffix(strings.TrimPrefix(s, "Nodes('"), "')"))
+			}
+		}
+		nodePath := strings.Join(segments, "/")
+		if strings.HasSuffix(p, "/$value") {
+			fmt.Fprint(w, nodePath)
+			return
+		}
+		results := make([]string, 0)
+		for _, name := range tree[nodePath] {
+			child := strings.TrimPrefix(nodePath+"/"+name, "/")
+			results = append(results, fmt.Sprintf(`{"Name":"%s","ContentLength":"%d","ChildrenNumber":"%d"}`, name, len(child), len(tree[child])))
+		}
+		fmt.Fprintf(w, `{"d":{"results":[%s]}}`, strings.Join(results, ","))
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	dst := t.TempDir()
+	filePaths, err := se.DownloadNodes("id", dst, "manifest.safe", "GRANULE/*/IMG_DATA/*_B04_10m.jp2")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	sort.Strings(filePaths)
+	expected := []string{
+		filepath.Join(dst, "P.SAFE", "GRANULE", "L2A_T36UYA", "IMG_DATA", "T36UYA_B04_10m.jp2"),
+		filepath.Join(dst, "P.SAFE", "manifest.safe"),
+	}
+	if fmt.Sprint(filePaths) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected files %v", filePaths)
+	}
+	bs, err := os.ReadFile(expected[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "P.SAFE/GRANULE/L2A_T36UYA/IMG_DATA/T36UYA_B04_10m.jp2" {
+		t.Errorf("unexpected content %s", bs)
+	}
+}
+
+func TestDownloadNodesInvalidName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value":[{"Name":"..","ContentLength":"1","ChildrenNumber":"0"}]}`)
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	dst := t.TempDir()
+	if _, err := se.DownloadNodes("id", dst, "*"); err == nil {
+		t.Errorf("error should not be nil for node escaping destination")
+	}
+	if files, _ := os.ReadDir(filepath.Dir(dst)); len(files) != 1 {
+		t.Errorf("nothing should be written outside destination")
+	}
+}
@@ -0,0 +1,66 @@
+package sentinel_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+func (se SentinelEngine) getProductURL(productID string) string {
+	return fmt.Sprintf("%s/Products('%s')", se.dhusURL, productID)
+}
+
+// getJSON requests OData resource in JSON format and decodes it into v.
+// DHuS wraps OData v2 entities into "d" object, newer services do not, both are supported.
+func (se SentinelEngine) getJSON(link string, v interface{}) (err error) {
+	if strings.Contains(link, "?") {
+		link += "&$format=json"
+	} else {
+		link += "?$format=json"
+	}
+	var (
+		status int
+		bs     []byte
+	)
+	ctx, op := se.obs.Start(context.Background(), telemetry.OpOData, link)
+	defer func() { op.End(status, int64(len(bs)), err) }()
+
+	req, err := se.newRequest(http.MethodGet, link)
+	if err != nil {
+		return fmt.Errorf("error on create request: %s", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := se.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error on GET %s: %w", link, err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	bs, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error on read response body: %s", err)
+	}
+
+	var wrapped struct {
+		D json.RawMessage `json:"d"`
+	}
+	if err := json.Unmarshal(bs, &wrapped); err == nil && len(wrapped.D) > 0 {
+		bs = wrapped.D
+	}
+	if err := json.Unmarshal(bs, v); err != nil {
+		return fmt.Errorf("error on parse OData response: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package sentinel_engine
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/storage"
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+const defaultBaseURL = "https://scihub.copernicus.eu/dhus"
+
+// Option configures engine created by NewSentinelEngine
+type Option func(*SentinelEngine)
+
+// WithBaseURL sets hub root URL, e.g. https://scihub.copernicus.eu/dhus.
+// OData requests are sent to <baseURL>/odata/v1
+func WithBaseURL(baseURL string) Option {
+	return func(se *SentinelEngine) {
+		se.dhusURL = strings.TrimRight(baseURL, "/") + "/odata/v1"
+	}
+}
+
+// WithHTTPClient sets http client used for OData requests. Timeout passed to
+// NewSentinelEngine is not applied to it
+func WithHTTPClient(c *http.Client) Option {
+	return func(se *SentinelEngine) {
+		if c != nil {
+			se.httpClient = c
+		}
+	}
+}
+
+// WithUserAgent sets User-Agent header of OData requests
+func WithUserAgent(userAgent string) Option {
+	return func(se *SentinelEngine) {
+		se.userAgent = userAgent
+	}
+}
+
+// WithTransport sets round tripper of http client, e.g. for proxy or TLS configuration.
+// Client provided with WithHTTPClient is not modified, its copy is used instead
+func WithTransport(rt http.RoundTripper) Option {
+	return func(se *SentinelEngine) {
+		se.transport = rt
+	}
+}
+
+// WithLimiter makes engine respect hub quotas: requests wait for the limiter and pause
+// on 429, downloads take a slot of concurrent downloads and triggered retrievals are counted.
+// Share one limiter between searcher and engines using the same account
+func WithLimiter(l *quota.Limiter) Option {
+	return func(se *SentinelEngine) {
+		se.limiter = l
+	}
+}
+
+// WithLogger enables logging of OData requests and downloads: URL without credentials,
+// status, bytes, latency and retries. Successful requests are logged at debug level
+func WithLogger(logger *slog.Logger) Option {
+	return func(se *SentinelEngine) {
+		se.obs.Logger = logger
+	}
+}
+
+// WithTracer enables tracing spans around each OData request and download
+func WithTracer(tracer telemetry.Tracer) Option {
+	return func(se *SentinelEngine) {
+		se.obs.Tracer = tracer
+	}
+}
+
+// WithMetrics makes engine report request, download, checksum and retrieval metrics, see telemetry/prometheus
+func WithMetrics(metrics telemetry.Metrics) Option {
+	return func(se *SentinelEngine) {
+		se.obs.Metrics = metrics
+	}
+}
+
+// WithCredentials makes engine ask p for credentials of the hub host before each request,
+// instead of user and password given to NewSentinelEngine
+func WithCredentials(p credentials.Provider) Option {
+	return func(se *SentinelEngine) {
+		se.auth = p
+	}
+}
+
+// WithLayout makes engine save products under path given by template, e.g.
+// {platform}/{tile}/{year}/{month}/{identifier}.zip. Directories are created as needed.
+// Fields are taken from entry given to DownloadEntry or parsed from the product file name
+func WithLayout(t layout.Template) Option {
+	return func(se *SentinelEngine) {
+		se.layout = &t
+	}
+}
+
+// WithCollision sets policy for products existing at destination, storage.Overwrite by default
+func WithCollision(c storage.Collision) Option {
+	return func(se *SentinelEngine) {
+		se.collision = c
+	}
+}
+
+// WithRequireChecksum makes engine refuse products without usable checksum with ErrUnverified,
+// instead of storing them with a warning
+func WithRequireChecksum() Option {
+	return func(se *SentinelEngine) {
+		se.requireChecksum = true
+	}
+}
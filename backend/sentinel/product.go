@@ -0,0 +1,141 @@
+package sentinel_engine
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+type odataContentDate struct {
+	Start string `json:"Start"`
+	End   string `json:"End"`
+}
+
+type odataProduct struct {
+	ID              string           `json:"Id"`
+	Name            string           `json:"Name"`
+	ContentLength   json.RawMessage  `json:"ContentLength"`
+	ContentDate     odataContentDate `json:"ContentDate"`
+	IngestionDate   string           `json:"IngestionDate"`   // DHuS
+	PublicationDate string           `json:"PublicationDate"` // CDSE
+	Online          *bool            `json:"Online"`
+	Checksum        json.RawMessage  `json:"Checksum"`
+	ContentGeometry string           `json:"ContentGeometry"` // DHuS, GML
+	Footprint       string           `json:"Footprint"`       // CDSE, geography'SRID=4326;POLYGON(...)'
+	Attributes      []odataAttribute `json:"Attributes"`      // CDSE, if expanded
+}
+
+type odataAttribute struct {
+	Name  string          `json:"Name"`
+	Value json.RawMessage `json:"Value"`
+}
+
+// parseODataTime parses both DHuS /Date(1641034800000)/ and ISO 8601 formats
+func parseODataTime(s string) (time.Time, error) {
+	if strings.HasPrefix(s, "/Date(") {
+		ms, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(s, "/Date("), ")/"), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// rawString returns JSON string content or JSON literal as is for numbers and booleans
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+// GetProduct returns product metadata and attributes by product ID without running a search
+func (se SentinelEngine) GetProduct(productID string) (sentinel.QueryEntryResponse, error) {
+	var entry sentinel.QueryEntryResponse
+	var product odataProduct
+	if err := se.getJSON(se.getProductURL(productID)+"?$expand=Attributes", &product); err != nil {
+		// DHuS does not support expanding attributes, other errors are not retried without it
+		var statusErr ErrStatus
+		if !errors.As(err, &statusErr) || (statusErr.Code != http.StatusBadRequest && statusErr.Code != http.StatusNotImplemented) {
+			return entry, fmt.Errorf("error on get product metadata: %w", err)
+		}
+		if err = se.getJSON(se.getProductURL(productID), &product); err != nil {
+			return entry, fmt.Errorf("error on get product metadata: %w", err)
+		}
+	}
+
+	attributes := product.Attributes
+	if len(attributes) == 0 {
+		var res struct {
+			Results []odataAttribute `json:"results"`
+			Value   []odataAttribute `json:"value"`
+		}
+		if err := se.getJSON(se.getURL(productID, "Attributes"), &res); err != nil {
+			return entry, fmt.Errorf("error on get product attributes: %w", err)
+		}
+		attributes = append(res.Results, res.Value...)
+	}
+
+	entry.ID = product.ID
+	entry.UUID = product.ID
+	entry.Title = strings.TrimSuffix(product.Name, ".SAFE")
+	entry.Identifier = entry.Title
+	entry.ContentLength, _ = strconv.ParseInt(rawString(product.ContentLength), 10, 64)
+	if product.Online != nil {
+		entry.Online = *product.Online
+	}
+	entry.GMLFootprint = product.ContentGeometry
+	if strings.HasPrefix(product.Footprint, "geography'") {
+		footprint := strings.TrimSuffix(strings.TrimPrefix(product.Footprint, "geography'"), "'")
+		if i := strings.Index(footprint, ";"); i >= 0 {
+			footprint = footprint[i+1:]
+		}
+		entry.Footprint = footprint
+	}
+	checksums, err := unpackChecksums(product.Checksum)
+	if err != nil {
+		return entry, fmt.Errorf("error on parse checksum: %s", err)
+	}
+	entry.Checksums = checksums
+
+	for field, value := range map[*time.Time]string{
+		&entry.BeginPosition: product.ContentDate.Start,
+		&entry.EndPosition:   product.ContentDate.End,
+		&entry.IngestionDate: cmp.Or(product.IngestionDate, product.PublicationDate),
+	} {
+		if value == "" {
+			continue
+		}
+		if *field, err = parseODataTime(value); err != nil {
+			return entry, fmt.Errorf("error on parse date %s: %s", value, err)
+		}
+	}
+
+	for _, a := range attributes {
+		name := sentinel.NormalizeAttributeName(a.Name)
+		value := rawString(a.Value)
+		if err = entry.SetAttribute(name, value); err != nil {
+			// OData dates are not always RFC3339, keep the raw value only
+			entry.Attributes[name] = value
+			if t, err := parseODataTime(value); err == nil {
+				entry.SetAttribute(name, t.Format(time.RFC3339Nano))
+			}
+		}
+	}
+	if entry.FileName == "" && strings.HasSuffix(product.Name, ".SAFE") {
+		entry.FileName = product.Name
+	}
+	return entry, nil
+}
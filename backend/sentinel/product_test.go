@@ -0,0 +1,104 @@
+package sentinel_engine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/therox/go-sentinel/quota"
+)
+
+func TestGetProductDHuS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/odata/v1/Products('uuid')":
+			if r.URL.Query().Get("$expand") != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"d":{"Id":"uuid","Name":"S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407",
+				"ContentLength":"1073741824","Online":true,
+				"ContentDate":{"Start":"/Date(1641026021024)/","End":"/Date(1641026021024)/"},
+				"IngestionDate":"/Date(1641036000000)/","PublicationDate":"2022-01-02T00:00:00Z",
+				"Checksum":{"Algorithm":"MD5","Value":"abc"}}}`)
+		case "/odata/v1/Products('uuid')/Attributes":
+			fmt.Fprint(w, `{"d":{"results":[
+				{"Name":"Cloud cover percentage","Value":"12.5"},
+				{"Name":"Tile Identifier","Value":"36UYA"},
+				{"Name":"Relative orbit (start)","Value":"21"},
+				{"Name":"Sensing start","Value":"2022-01-01T08:33:41.024Z"},
+				{"Name":"Satellite name","Value":"Sentinel-2"},
+				{"Name":"Custom attribute","Value":"x"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	entry, err := se.GetProduct("uuid")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if entry.UUID != "uuid" || entry.Identifier != "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407" {
+		t.Errorf("unexpected id %s or identifier %s", entry.UUID, entry.Identifier)
+	}
+	if entry.ContentLength != 1073741824 || !entry.Online {
+		t.Errorf("unexpected content length %d or online %t", entry.ContentLength, entry.Online)
+	}
+	if entry.CloudCoverPercentage != 12.5 || entry.TileId != "36UYA" || entry.RelativeOrbitNumber != 21 || entry.PlatformName != "Sentinel-2" {
+		t.Errorf("attributes are not mapped: %+v", entry.Attributes)
+	}
+	if !entry.IngestionDate.Equal(time.Date(2022, 1, 1, 11, 20, 0, 0, time.UTC)) {
+		t.Errorf("unexpected ingestion date %s", entry.IngestionDate)
+	}
+	if entry.BeginPosition.UnixMilli() != 1641026021024 {
+		t.Errorf("unexpected begin position %s", entry.BeginPosition)
+	}
+	if len(entry.Checksums) != 1 || entry.Checksums[0].Value != "abc" {
+		t.Errorf("unexpected checksums %v", entry.Checksums)
+	}
+	if entry.Attributes["customattribute"] != "x" {
+		t.Errorf("unknown attribute is not kept")
+	}
+}
+
+func TestGetProductNoFallback(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	_, err := se.GetProduct("uuid")
+	if err == nil {
+		t.Fatalf("err is nil but should not be")
+	}
+	if requests != 1 {
+		t.Errorf("product should be requested once, but is requested %d times", requests)
+	}
+}
+
+func TestGetProductQuota(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(srv.URL))
+	var qe quota.ErrQuotaExceeded
+	if _, err := se.GetProduct("uuid"); !errors.As(err, &qe) {
+		t.Errorf("error should be ErrQuotaExceeded, but is %v", err)
+	}
+	if _, err := se.Checksums("uuid"); !errors.As(err, &qe) {
+		t.Errorf("error should be ErrQuotaExceeded, but is %v", err)
+	}
+	if _, err := se.Nodes("uuid", ""); !errors.As(err, &qe) {
+		t.Errorf("error should be ErrQuotaExceeded, but is %v", err)
+	}
+}
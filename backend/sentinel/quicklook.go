@@ -0,0 +1,55 @@
+package sentinel_engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+// DownloadQuicklook downloads product preview image into dst directory. Returns local file path
+func (se SentinelEngine) DownloadQuicklook(productID string, dst string) (filePath string, err error) {
+	link := se.getURL(productID, "Products('Quicklook')/$value")
+	var status int
+	var written int64
+	ctx, op := se.obs.Start(context.Background(), telemetry.OpDownloadQuicklook, link)
+	defer func() { op.End(status, written, err) }()
+
+	req, err := se.newRequest(http.MethodGet, link)
+	if err != nil {
+		return filePath, fmt.Errorf("error on create request: %s", err)
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := se.httpClient.Do(req)
+	if err != nil {
+		return filePath, fmt.Errorf("error on GET quicklook: %s", err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	if resp.StatusCode != http.StatusOK {
+		return filePath, statusError(resp)
+	}
+
+	filePath = filepath.Join(dst, sentinel.QuicklookFileName(productID, resp.Header))
+	out, err := os.Create(filePath)
+	if err != nil {
+		return filePath, fmt.Errorf("error on create local file: %s", err)
+	}
+
+	written, err = io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return filePath, fmt.Errorf("error on saving quicklook: %s", err)
+	}
+	return filePath, nil
+}
@@ -1,15 +1,21 @@
 package sentinel_engine
 
 import (
-	"crypto/md5"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/storage"
+	"github.com/therox/go-sentinel/telemetry"
 )
 
 type (
@@ -18,6 +24,24 @@ type (
 	}
 	ErrIntegrityError struct {
 		productID string
+		algorithm string
+		expected  string
+		actual    string
+	}
+	// ErrStatus is returned for unexpected status of hub response
+	ErrStatus struct {
+		Code    int
+		Message string // Cause-Message header
+	}
+	ErrUnsupportedChecksum struct {
+		productID  string
+		algorithms []string
+	}
+	// ErrUnverified is returned by engines created WithRequireChecksum for products which
+	// can not be verified, e.g. if metadata is unavailable and the hub sent no Etag
+	ErrUnverified struct {
+		productID string
+		reason    error
 	}
 )
 
@@ -26,7 +50,32 @@ func (e ErrFileTriggered) Error() string {
 }
 
 func (e ErrIntegrityError) Error() string {
-	return fmt.Sprintf("dataset %s integrity error: checksum mismatch", e.productID)
+	return fmt.Sprintf("dataset %s integrity error: %s checksum mismatch, expected %s, got %s", e.productID, e.algorithm, e.expected, e.actual)
+}
+
+func (e ErrStatus) Error() string {
+	return fmt.Sprintf("%d:%s", e.Code, e.Message)
+}
+
+func (e ErrUnsupportedChecksum) Error() string {
+	return fmt.Sprintf("dataset %s has no checksum of supported algorithm: %s", e.productID, strings.Join(e.algorithms, ", "))
+}
+
+func (e ErrUnverified) Error() string {
+	return fmt.Sprintf("dataset %s is not verified: %s", e.productID, e.reason)
+}
+
+func (e ErrUnverified) Unwrap() error {
+	return e.reason
+}
+
+// statusError returns quota.ErrQuotaExceeded if the hub refused request for quota and
+// ErrStatus otherwise
+func statusError(resp *http.Response) error {
+	if err := quota.ParseResponse(resp); err != nil {
+		return err
+	}
+	return ErrStatus{Code: resp.StatusCode, Message: resp.Header.Get("Cause-Message")}
 }
 
 type SentinelEngine struct {
@@ -34,21 +83,62 @@ type SentinelEngine struct {
 	password   string
 	httpClient *http.Client
 	dhusURL    string
+	userAgent  string
+	transport  http.RoundTripper
+	limiter    *quota.Limiter
+	obs        telemetry.Observer
+	auth       credentials.Provider
+	layout     *layout.Template
+	collision  storage.Collision
+
+	requireChecksum bool
 }
 
 // NewSentinelEngine returns a new SentinelEngine
-func NewSentinelEngine(user string, password string, httpTimeout time.Duration) SentinelEngine {
+func NewSentinelEngine(user string, password string, httpTimeout time.Duration, opts ...Option) SentinelEngine {
 	// Function creates new SentinelEngine with given user and password and http client with given timeout.
 	// If timeout equals 0 then notimeout is used.
 
-	return SentinelEngine{
+	se := SentinelEngine{
 		user:     user,
 		password: password,
 		httpClient: &http.Client{
 			Timeout: httpTimeout,
 		},
-		dhusURL: "https://scihub.copernicus.eu/dhus/odata/v1",
+		dhusURL: defaultBaseURL + "/odata/v1",
+	}
+	for _, opt := range opts {
+		opt(&se)
+	}
+	if se.transport != nil || se.limiter != nil || se.auth != nil {
+		c := *se.httpClient
+		if se.transport != nil {
+			c.Transport = se.transport
+		}
+		c.Transport = credentials.Transport(se.auth, se.limiter.Transport(c.Transport))
+		se.httpClient = &c
+	}
+	return se
+}
+
+func (se SentinelEngine) newRequest(method string, link string) (*http.Request, error) {
+	req, err := http.NewRequest(method, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if se.auth != nil {
+		c, err := se.auth.Credentials(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("error on get credentials for %s: %s", req.URL.Hostname(), err)
+		}
+		req.SetBasicAuth(c.User, c.Password)
+	} else {
+		req.SetBasicAuth(se.user, se.password)
+	}
+	if se.userAgent != "" {
+		req.Header.Set("User-Agent", se.userAgent)
 	}
+	return req, nil
 }
 
 func (se SentinelEngine) getURL(product_id string, suffix string) string {
@@ -56,79 +146,197 @@ func (se SentinelEngine) getURL(product_id string, suffix string) string {
 }
 
 func (se SentinelEngine) Download(productID string, dst string) (string, error) {
-	filePath := ""
+	return se.DownloadTo(productID, storage.Local{Dir: dst})
+}
+
+// DownloadTo streams product to sink. Checksum is verified while streaming, the object is
+// committed only if it matches. Products without usable checksum are stored with a warning logged,
+// or refused with ErrUnverified if engine is created WithRequireChecksum. Location of the object is returned
+func (se SentinelEngine) DownloadTo(productID string, sink storage.Sink) (string, error) {
+	return se.download(productID, sentinel.QueryEntryResponse{}, sink)
+}
+
+// DownloadEntry downloads entry product into dst directory. Entry fields are used by the layout
+func (se SentinelEngine) DownloadEntry(entry sentinel.QueryEntryResponse, dst string) (string, error) {
+	return se.DownloadEntryTo(entry, storage.Local{Dir: dst})
+}
+
+// DownloadEntryTo streams entry product to sink. Entry fields are used by the layout
+func (se SentinelEngine) DownloadEntryTo(entry sentinel.QueryEntryResponse, sink storage.Sink) (string, error) {
+	id := entry.GetID()
+	if id == "" {
+		id = entry.UUID
+	}
+	if id == "" {
+		return "", fmt.Errorf("entry has no ID")
+	}
+	return se.download(id, entry, sink)
+}
+
+// target returns name of product object in sink according to layout and collision policy,
+// or location of existing object to keep
+func (se SentinelEngine) target(ctx context.Context, entry sentinel.QueryEntryResponse, fileName string, sink storage.Sink) (string, string, error) {
+	name := fileName
+	if se.layout != nil {
+		entry.FileName = fileName
+		rel, err := se.layout.Path(entry)
+		if err != nil {
+			return "", "", err
+		}
+		name = filepath.ToSlash(rel)
+	}
+	return storage.Resolve(ctx, sink, name, se.collision)
+}
+
+func (se SentinelEngine) download(productID string, entry sentinel.QueryEntryResponse, sink storage.Sink) (location string, err error) {
 	link := se.getURL(productID, "$value")
 
-	req, err := http.NewRequest("GET", link, nil)
+	// With entry known the name does not depend on the response, so existing product
+	// is kept without requesting it and triggering retrieval
+	var name string
+	if se.layout != nil && layout.Complete(entry).Identifier != "" {
+		name, location, err = se.target(context.Background(), entry, "", sink)
+		if err != nil || location != "" {
+			return location, err
+		}
+	}
+
+	// Metadata checksum is preferred, Etag (MD5) is used if metadata is not available
+	checksums, checksumsErr := se.Checksums(productID)
+
+	// Online status costs a request, so it is checked only when retrieval quota is exhausted
+	if se.limiter.RetrievalDelay() > 0 {
+		if isOnline, err := se.IsOnline(productID); err == nil && !isOnline {
+			if err = se.limiter.WaitRetrieval(context.Background()); err != nil {
+				return location, err
+			}
+		}
+	}
+
+	release, err := se.limiter.AcquireDownload(context.Background())
 	if err != nil {
-		return filePath, fmt.Errorf("error on create request: %s", err)
+		return location, err
 	}
-	req.SetBasicAuth(se.user, se.password)
+	defer release()
+
+	var status int
+	var written int64
+	ctx, op := se.obs.Start(context.Background(), telemetry.OpDownload, link)
+	defer func() { op.End(status, written, err) }()
+
+	req, err := se.newRequest(http.MethodGet, link)
+	if err != nil {
+		return location, fmt.Errorf("error on create request: %s", err)
+	}
+	req = req.WithContext(ctx)
 
 	resp, err := se.httpClient.Do(req)
 	if err != nil {
-		return filePath, fmt.Errorf("error on GET file: %s", err)
+		return location, fmt.Errorf("error on GET file: %s", err)
 	}
 
 	defer resp.Body.Close()
+	status = resp.StatusCode
 
 	if resp.StatusCode == 202 {
-		return filePath, ErrFileTriggered{productID: productID}
+		se.limiter.RecordRetrieval()
+		se.obs.RetrievalTriggered()
+		return location, ErrFileTriggered{productID: productID}
 	}
 
 	if resp.StatusCode != 200 {
 
-		return filePath, fmt.Errorf("%d:%s", resp.StatusCode, resp.Header.Get("Cause-Message"))
+		return location, statusError(resp)
 	}
 
-	_, err = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
 	if err != nil {
-		return filePath, fmt.Errorf("error on parse Content-Length: %s", err)
+		return location, fmt.Errorf("error on parse Content-Length: %s", err)
 	}
 
-	dst_fileName := strings.Trim(strings.TrimSpace(strings.Split(resp.Header.Get("Content-Disposition"), "=")[1]), "\"")
+	// file name comes from the hub, it must not add directories to the destination
+	dst_fileName := productID + ".zip"
+	if _, name, ok := strings.Cut(resp.Header.Get("Content-Disposition"), "="); ok {
+		if name = storage.SafeName(strings.Trim(strings.TrimSpace(name), "\"")); name != "" {
+			dst_fileName = name
+		}
+	}
 
-	checkSum := resp.Header.Get("Etag")
+	if etag := strings.Trim(resp.Header.Get("Etag"), "\""); len(checksums) == 0 && etag != "" {
+		checksums = []Checksum{{Algorithm: AlgorithmMD5, Value: etag}}
+	}
+	h, expected, isVerifiable := selectHash(checksums)
 
-	filePath = path.Join(dst, dst_fileName)
-	out, err := os.Create(filePath)
+	if name == "" {
+		name, location, err = se.target(ctx, entry, dst_fileName, sink)
+		if err != nil || location != "" {
+			return location, err
+		}
+	}
+
+	out, err := storage.Create(ctx, sink, name, size, se.collision)
 	if err != nil {
-		return filePath, fmt.Errorf("error on create local file: %s", err)
+		return location, err
 	}
-	defer out.Close()
 
-	hashMD5 := md5.New()
-	w := io.MultiWriter(out, hashMD5)
+	var w io.Writer = out
+	if isVerifiable {
+		w = io.MultiWriter(out, h)
+	}
 
-	_, err = io.Copy(w, resp.Body)
+	written, err = io.Copy(w, resp.Body)
 	if err != nil {
-		return filePath, fmt.Errorf("error on saving file: %s", err)
+		out.Abort()
+		return location, fmt.Errorf("error on saving file: %s", err)
 	}
 
-	if checkSum != fmt.Sprintf("%x", hashMD5.Sum(nil)) {
-		os.RemoveAll(filePath)
-		return filePath, fmt.Errorf(ErrIntegrityError{productID: productID}.Error())
+	if isVerifiable {
+		if err = se.reportChecksum(checkSum(productID, h, expected)); err != nil {
+			out.Abort()
+			return location, err
+		}
 	}
 
-	return filePath, nil
+	if !isVerifiable {
+		reason := checksumsErr
+		if len(checksums) > 0 {
+			reason = unsupportedChecksum(productID, checksums)
+		} else if reason == nil {
+			reason = fmt.Errorf("hub published no checksum")
+		}
+		if se.requireChecksum {
+			out.Abort()
+			return location, ErrUnverified{productID: productID, reason: reason}
+		}
+		op.Warn("download not verified", reason)
+	}
+
+	return out.Commit()
 }
 
-func (se SentinelEngine) IsOnline(productID string) (bool, error) {
+func (se SentinelEngine) IsOnline(productID string) (isOnline bool, err error) {
 	link := se.getURL(productID, "Online/$value")
+	var (
+		status int
+		bs     []byte
+	)
+	ctx, op := se.obs.Start(context.Background(), telemetry.OpOData, link)
+	defer func() { op.End(status, int64(len(bs)), err) }()
 
-	req, err := http.NewRequest(http.MethodGet, link, nil)
+	req, err := se.newRequest(http.MethodGet, link)
 	if err != nil {
 		return false, fmt.Errorf("error on create request: %s", err)
 	}
-	req.SetBasicAuth(se.user, se.password)
+	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := se.httpClient.Do(req)
 	if err != nil {
 		return false, fmt.Errorf("error on GET Online status: %s", err)
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
 
-	bs, err := io.ReadAll(resp.Body)
+	bs, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return false, fmt.Errorf("error on read response body: %s", err)
 	}
@@ -0,0 +1,223 @@
+package sentinel_engine
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/sentineltest"
+	"github.com/therox/go-sentinel/storage"
+)
+
+func TestDownloadHub(t *testing.T) {
+	content := sentineltest.ProductContent(1 << 16)
+	hub := sentineltest.NewHub(
+		sentineltest.Product{UUID: "online", Identifier: "S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818", Content: content, Online: true},
+		sentineltest.Product{UUID: "offline", Content: content},
+	)
+	defer hub.Close()
+	hub.SetCredentials("user", "password")
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(hub.URL))
+
+	dst := t.TempDir()
+	filePath, err := se.Download("online", dst)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if filePath != filepath.Join(dst, "S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818.zip") {
+		t.Errorf("unexpected file path %s", filePath)
+	}
+	bs, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if !bytes.Equal(bs, content) {
+		t.Errorf("downloaded content differs")
+	}
+	if err = se.Verify("online", filePath); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+
+	isOnline, err := se.IsOnline("offline")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if isOnline {
+		t.Errorf("product should be offline")
+	}
+	_, err = se.Download("offline", dst)
+	var ft ErrFileTriggered
+	if !errors.As(err, &ft) {
+		t.Fatalf("error should be ErrFileTriggered, but is %v", err)
+	}
+	if !hub.Triggered("offline") {
+		t.Errorf("retrieval of offline product should be triggered")
+	}
+
+	hub.SetOnline("offline", true)
+	if isOnline, _ = se.IsOnline("offline"); !isOnline {
+		t.Errorf("product should be online")
+	}
+
+	hub.FailNext(sentineltest.EndpointDownload, 429, 500)
+	for _, status := range []string{"429", "500"} {
+		if _, err = se.Download("offline", dst); err == nil || !strings.HasPrefix(err.Error(), status) {
+			t.Errorf("error should start with %s, but is %v", status, err)
+		}
+	}
+	if _, err = se.Download("offline", dst); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+}
+
+func TestDownloadLimiter(t *testing.T) {
+	hub := sentineltest.NewHub(
+		sentineltest.Product{UUID: "a"},
+		sentineltest.Product{UUID: "b"},
+		sentineltest.Product{UUID: "c", Content: sentineltest.ProductContent(128), Online: true},
+	)
+	defer hub.Close()
+	l := quota.New(quota.WithRetrievals(1, time.Hour), quota.WithRetries(1, time.Millisecond, time.Millisecond), quota.WithMaxWait(time.Minute))
+	se := NewSentinelEngine("user", "password", 0, WithBaseURL(hub.URL), WithLimiter(l))
+	dst := t.TempDir()
+
+	var ft ErrFileTriggered
+	if _, err := se.Download("a", dst); !errors.As(err, &ft) {
+		t.Fatalf("error should be ErrFileTriggered, but is %v", err)
+	}
+	var qe quota.ErrQuotaExceeded
+	if _, err := se.Download("b", dst); !errors.As(err, &qe) || qe.Kind != quota.KindRetrievals {
+		t.Fatalf("error should be retrievals ErrQuotaExceeded, but is %v", err)
+	}
+	if hub.Triggered("b") {
+		t.Errorf("retrieval should not be triggered over quota")
+	}
+
+	// online products are not affected by retrieval quota, 429 is retried
+	hub.FailNext(sentineltest.EndpointDownload, 429)
+	if _, err := se.Download("c", dst); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+	hub.FailNext(sentineltest.EndpointDownload, 429, 429)
+	if _, err := se.Download("c", dst); !errors.As(err, &qe) || qe.Kind != quota.KindRequests {
+		t.Errorf("error should be requests ErrQuotaExceeded, but is %v", err)
+	}
+}
+
+func TestDownloadLogging(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Content: sentineltest.ProductContent(256), Online: true})
+	defer hub.Close()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	l := quota.New(quota.WithRetries(1, time.Millisecond, time.Millisecond))
+	se := NewSentinelEngine("user", "secret", 0, WithBaseURL(hub.URL), WithLimiter(l), WithLogger(logger))
+
+	hub.FailNext(sentineltest.EndpointDownload, 429)
+	if _, err := se.Download("id", t.TempDir()); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	log := buf.String()
+	for _, s := range []string{"op=sentinel.odata", "op=sentinel.download", "request retried", "status=200 bytes=256", "retries=1"} {
+		if !strings.Contains(log, s) {
+			t.Errorf("log should contain %s: %s", s, log)
+		}
+	}
+	if strings.Contains(log, "secret") {
+		t.Errorf("log should not contain credentials")
+	}
+}
+
+func TestDownloadTo(t *testing.T) {
+	content := sentineltest.ProductContent(6 << 20)
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Identifier: "S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818", Content: content, Online: true})
+	defer hub.Close()
+	s3 := sentineltest.NewS3("products", "AKID")
+	defer s3.Close()
+	se := NewSentinelEngine("", "", 0, WithBaseURL(hub.URL))
+
+	sink := storage.NewS3(s3.URL, "products", storage.WithS3Credentials(credentials.Static("AKID", "secret")), storage.WithPartSize(storage.MinPartSize))
+	location, err := se.DownloadTo("id", sink)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if location != s3.URL+"/products/S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818.zip" {
+		t.Errorf("unexpected location %s", location)
+	}
+	if bs, _ := s3.Object("S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818.zip"); !bytes.Equal(bs, content) {
+		t.Errorf("stored content differs")
+	}
+	if s3.CompletedUploads() != 1 {
+		t.Errorf("product should be uploaded in parts")
+	}
+
+	var buf bytes.Buffer
+	if _, err = se.DownloadTo("id", storage.Writer(&buf)); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("written content differs")
+	}
+}
+
+func TestDownloadLayout(t *testing.T) {
+	name := "S2B_MSIL2A_20220105T083229_N0301_R021_T36UYA_20220105T103818"
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Identifier: name, Content: sentineltest.ProductContent(256), Online: true})
+	defer hub.Close()
+	tmpl := layout.MustParse("{platform}/{tile}/{year}/{month}/{identifier}.zip")
+	dst := t.TempDir()
+	expected := filepath.Join(dst, "Sentinel-2", "36UYA", "2022", "01", name+".zip")
+
+	se := NewSentinelEngine("", "", 0, WithBaseURL(hub.URL), WithLayout(tmpl), WithCollision(storage.Rename))
+	filePath, err := se.Download("id", dst)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if filePath != expected {
+		t.Errorf("file path should be %s, but is %s", expected, filePath)
+	}
+	filePath, err = se.Download("id", dst)
+	if err != nil || filePath != strings.TrimSuffix(expected, ".zip")+"_1.zip" {
+		t.Errorf("existing file should not be overwritten, got %s, error %v", filePath, err)
+	}
+
+	// entry fields are used and the product is not requested if kept
+	se = NewSentinelEngine("", "", 0, WithBaseURL(hub.URL), WithLayout(tmpl), WithCollision(storage.Skip))
+	requests := hub.Requests(sentineltest.EndpointDownload)
+	filePath, err = se.DownloadEntry(sentinel.QueryEntryResponse{UUID: "id", Identifier: name}, dst)
+	if err != nil || filePath != expected {
+		t.Errorf("existing file should be kept, got %s, error %v", filePath, err)
+	}
+	if hub.Requests(sentineltest.EndpointDownload) != requests {
+		t.Errorf("skipped product should not be requested")
+	}
+
+	se = NewSentinelEngine("", "", 0, WithBaseURL(hub.URL), WithLayout(tmpl), WithCollision(storage.Fail))
+	var ee storage.ErrExists
+	if _, err = se.Download("id", dst); !errors.As(err, &ee) || ee.Location != expected {
+		t.Errorf("error should be ErrExists, but is %v", err)
+	}
+}
+
+func TestDownloadQuicklook(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Quicklook: []byte("jpg")})
+	defer hub.Close()
+	se := NewSentinelEngine("", "", 0, WithBaseURL(hub.URL))
+
+	dst := t.TempDir()
+	filePath, err := se.DownloadQuicklook("id", dst)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if filePath != filepath.Join(dst, "id.jpg") {
+		t.Errorf("unexpected file path %s", filePath)
+	}
+}
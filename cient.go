@@ -3,6 +3,13 @@ package sentinel
 import (
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/storage"
+	"github.com/therox/go-sentinel/telemetry"
 )
 
 type SentinelClient struct {
@@ -20,17 +27,67 @@ type sentinelSearcher struct {
 	httpClient *http.Client
 	searchURL  string
 	rows       int
+	userAgent  string
+	transport  http.RoundTripper
+	limiter    *quota.Limiter
+	obs        telemetry.Observer
+	auth       credentials.Provider
+
+	pageAttempts int
+	pageBackoff  time.Duration
 }
 
-func NewSentinelSearcher(user string, password string) ISentinelSearcher {
-	return sentinelSearcher{
+func NewSentinelSearcher(user string, password string, opts ...SearcherOption) ISentinelSearcher {
+	ss := sentinelSearcher{
 		user:       user,
 		password:   password,
 		httpClient: &http.Client{},
-		searchURL:  "https://scihub.copernicus.eu/dhus/search?q=",
+		searchURL:  defaultBaseURL + "/search?q=",
 		// searchURL: "https://apihub.copernicus.eu/apihub/search?q=",
-		rows: 100,
+		rows:         100,
+		pageAttempts: 5,
+		pageBackoff:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(&ss)
+	}
+	if ss.transport != nil || ss.limiter != nil || ss.auth != nil {
+		c := *ss.httpClient
+		if ss.transport != nil {
+			c.Transport = ss.transport
+		}
+		c.Transport = credentials.Transport(ss.auth, ss.limiter.Transport(c.Transport))
+		ss.httpClient = &c
+	}
+	return ss
+}
+
+func (ss sentinelSearcher) newRequest(method string, link string) (*http.Request, error) {
+	req, err := http.NewRequest(method, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ss.auth != nil {
+		c, err := ss.auth.Credentials(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("error on get credentials for %s: %s", req.URL.Hostname(), err)
+		}
+		req.SetBasicAuth(c.User, c.Password)
+	} else {
+		req.SetBasicAuth(ss.user, ss.password)
 	}
+	if ss.userAgent != "" {
+		req.Header.Set("User-Agent", ss.userAgent)
+	}
+	return req, nil
+}
+
+func (ss sentinelSearcher) get(link string) (*http.Response, error) {
+	req, err := ss.newRequest(http.MethodGet, link)
+	if err != nil {
+		return nil, fmt.Errorf("error on create request: %s", err)
+	}
+	return ss.httpClient.Do(req)
 }
 
 // func NewClient(user string, password string, httpTimeout time.Duration) *SentinelClient {
@@ -56,6 +113,66 @@ func (c *SentinelClient) Download(id string, dst string) (string, error) {
 	return c.dlEngine.Download(id, dst)
 }
 
+// DownloadTo streams product to sink, e.g. S3 bucket, if download engine supports it
+func (c *SentinelClient) DownloadTo(id string, sink storage.Sink) (string, error) {
+	sd, ok := c.dlEngine.(sinkDownloader)
+	if !ok {
+		return "", fmt.Errorf("download engine does not support storage sinks")
+	}
+
+	return sd.DownloadTo(id, sink)
+}
+
+// DownloadEntry downloads entry product into dst directory. Engines with path layout take
+// layout fields from the entry, others download by ID
+func (c *SentinelClient) DownloadEntry(entry QueryEntryResponse, dst string) (string, error) {
+	if ed, ok := c.dlEngine.(entryDownloader); ok {
+		return ed.DownloadEntry(entry, dst)
+	}
+	id := entry.GetID()
+	if id == "" {
+		id = entry.UUID
+	}
+	return c.Download(id, dst)
+}
+
+type DownloadResult struct {
+	Entry QueryEntryResponse
+	Path  string
+	Err   error
+}
+
+// DownloadEntries downloads products of all entries into dst directory using given number of workers.
+// Results are in the order of entries.
+func (c *SentinelClient) DownloadEntries(entries []QueryEntryResponse, dst string, workers int) []DownloadResult {
+	results := make([]DownloadResult, len(entries))
+	parallel(len(entries), workers, func(i int) {
+		results[i].Entry = entries[i]
+		results[i].Path, results[i].Err = c.DownloadEntry(entries[i], dst)
+	})
+	return results
+}
+
+// parallel calls fn for indexes 0..n-1 using given number of workers, at least one
+func parallel(n int, workers int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
 func (c *SentinelClient) IsOnline(id string) (bool, error) {
 	if c.dlEngine == nil {
 		return false, fmt.Errorf("no download engine available")
@@ -63,3 +180,13 @@ func (c *SentinelClient) IsOnline(id string) (bool, error) {
 
 	return c.dlEngine.IsOnline(id)
 }
+
+// GetProduct returns product metadata by ID if download engine supports it
+func (c *SentinelClient) GetProduct(id string) (QueryEntryResponse, error) {
+	pg, ok := c.dlEngine.(productGetter)
+	if !ok {
+		return QueryEntryResponse{}, fmt.Errorf("download engine does not provide product metadata")
+	}
+
+	return pg.GetProduct(id)
+}
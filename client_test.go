@@ -1,6 +1,8 @@
 package sentinel
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
 )
 
@@ -34,3 +36,61 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("err is nil %s but should not be", err)
 	}
 }
+
+func TestSearcherOptions(t *testing.T) {
+	rt := http.DefaultTransport
+	c := &http.Client{}
+	ss := NewSentinelSearcher("user", "password",
+		WithBaseURL("https://mirror.example.org/dhus/"),
+		WithPageSize(50),
+		WithPageSize(0),
+		WithHTTPClient(c),
+		WithTransport(rt),
+		WithUserAgent("go-sentinel-test"),
+	).(sentinelSearcher)
+
+	if ss.searchURL != "https://mirror.example.org/dhus/search?q=" {
+		t.Errorf("unexpected search URL %s", ss.searchURL)
+	}
+	if ss.rows != 50 {
+		t.Errorf("rows should be 50, but is %d", ss.rows)
+	}
+	if ss.httpClient.Transport != rt {
+		t.Errorf("transport is not applied")
+	}
+	if c.Transport != nil {
+		t.Errorf("provided http client should not be modified")
+	}
+	req, err := ss.newRequest(http.MethodGet, ss.searchURL)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if req.UserAgent() != "go-sentinel-test" {
+		t.Errorf("unexpected User-Agent %s", req.UserAgent())
+	}
+}
+
+type entryDlEngine struct {
+	mockDlEngine
+}
+
+func (m entryDlEngine) DownloadEntry(entry QueryEntryResponse, dst string) (string, error) {
+	if entry.Identifier == "" {
+		return "", fmt.Errorf("no identifier")
+	}
+	return dst + "/" + entry.Identifier + ".zip", nil
+}
+
+func TestDownloadEntries(t *testing.T) {
+	c, _ := NewClient(mockSentinelSearcher{}, entryDlEngine{})
+	entries := []QueryEntryResponse{{ID: "a", Identifier: "A"}, {ID: "b"}, {ID: "c", Identifier: "C"}}
+	results := c.DownloadEntries(entries, "dst", 2)
+	if len(results) != 3 || results[0].Path != "dst/A.zip" || results[1].Err == nil || results[2].Entry.ID != "c" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	c, _ = NewClient(mockSentinelSearcher{}, mockDlEngine{path: "dst/a.zip"})
+	if filePath, err := c.DownloadEntry(entries[0], "dst"); err != nil || filePath != "dst/a.zip" {
+		t.Errorf("engine without entry support should download by ID, got %s, error %v", filePath, err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+	"github.com/therox/go-sentinel/scheduler"
+)
+
+// api serves job queue over HTTP:
+//
+//	GET    /jobs[?status=pending,failed]  list jobs
+//	POST   /jobs                          add jobs for product IDs and/or search results
+//	GET    /jobs/{id}                     job status
+//	DELETE /jobs/{id}                     cancel job
+//	POST   /jobs/{id}/retry               retry failed or canceled job
+//	GET    /watches                       list saved searches
+//	POST   /watches/{name}/run            run saved search now, new products are queued
+//	GET    /healthz
+//
+// If token is set, requests other than /healthz should have "Authorization: Bearer <token>" header.
+type api struct {
+	queue     *queue.Queue
+	searcher  sentinel.ISentinelSearcher
+	dst       string // default destination directory, jobs can not be saved outside of it
+	scheduler *scheduler.Scheduler
+	watches   []scheduler.Watch
+	token     string
+}
+
+// jobsRequest is a body of POST /jobs
+type jobsRequest struct {
+	ProductIDs []string                   `json:"product_ids"`
+	Search     *sentinel.SearchParameters `json:"search"`
+	Dst        string                     `json:"dst"` // relative to default destination directory
+}
+
+type jobsResponse struct {
+	Jobs []queue.Job `json:"jobs"`
+}
+
+func (a api) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /jobs", a.listJobs)
+	mux.HandleFunc("POST /jobs", a.addJobs)
+	mux.HandleFunc("GET /jobs/{id}", a.getJob)
+	mux.HandleFunc("DELETE /jobs/{id}", a.cancelJob)
+	mux.HandleFunc("POST /jobs/{id}/retry", a.retryJob)
+	mux.HandleFunc("GET /watches", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]scheduler.Watch{"watches": a.watches})
+	})
+	mux.HandleFunc("POST /watches/{name}/run", a.runWatch)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return a.authorize(mux)
+}
+
+// authorize rejects requests without bearer token if it is set
+func (a api) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if a.token != "" && r.URL.Path != "/healthz" &&
+			(!ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (a api) listJobs(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]queue.Status, 0)
+	if s := r.URL.Query().Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			statuses = append(statuses, queue.Status(strings.TrimSpace(status)))
+		}
+	}
+	writeJSON(w, http.StatusOK, jobsResponse{Jobs: a.queue.List(statuses...)})
+}
+
+func (a api) addJobs(w http.ResponseWriter, r *http.Request) {
+	var req jobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("error on parse request: %s", err))
+		return
+	}
+	if len(req.ProductIDs) == 0 && req.Search == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("product_ids or search should be provided"))
+		return
+	}
+	// request can only choose a subdirectory of the default destination
+	dst := a.dst
+	if req.Dst != "" {
+		if !filepath.IsLocal(req.Dst) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("dst %s should be relative and not leave download directory", req.Dst))
+			return
+		}
+		dst = filepath.Join(a.dst, req.Dst)
+	}
+
+	res := jobsResponse{Jobs: make([]queue.Job, 0)}
+	for _, id := range req.ProductIDs {
+		job, err := a.queue.Add(id, dst, "", "api")
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		res.Jobs = append(res.Jobs, job)
+	}
+	if req.Search != nil {
+		qr, err := a.searcher.Query(*req.Search)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Errorf("error on search: %s", err))
+			return
+		}
+		for _, e := range qr.Feed.Entries {
+			id := e.GetID()
+			if id == "" {
+				id = e.UUID
+			}
+			job, err := a.queue.Add(id, dst, e.Identifier, "search")
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			res.Jobs = append(res.Jobs, job)
+		}
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (a api) getJob(w http.ResponseWriter, r *http.Request) {
+	job, err := a.queue.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (a api) cancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := a.queue.Cancel(r.PathValue("id"))
+	a.transition(w, job, err)
+}
+
+func (a api) retryJob(w http.ResponseWriter, r *http.Request) {
+	job, err := a.queue.Retry(r.PathValue("id"))
+	a.transition(w, job, err)
+}
+
+func (a api) transition(w http.ResponseWriter, job queue.Job, err error) {
+	switch {
+	case errors.Is(err, queue.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+func (a api) runWatch(w http.ResponseWriter, r *http.Request) {
+	for _, watch := range a.watches {
+		if watch.Name != r.PathValue("name") {
+			continue
+		}
+		entries, err := a.scheduler.RunWatch(r.Context(), watch)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"new": len(entries)})
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("watch %s not found", r.PathValue("name")))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+	"github.com/therox/go-sentinel/scheduler"
+)
+
+type testSearcher struct{}
+
+func (testSearcher) Query(params sentinel.SearchParameters) (sentinel.QueryResponse, error) {
+	var qr sentinel.QueryResponse
+	for _, tile := range params.TileIDs {
+		qr.Feed.Entries = append(qr.Feed.Entries, sentinel.QueryEntryResponse{ID: "uuid-" + tile, UUID: "uuid-" + tile, Identifier: "S2A_" + tile})
+	}
+	return qr, nil
+}
+
+func do(t *testing.T, h http.Handler, method string, link string, body string, v interface{}) int {
+	req := httptest.NewRequest(method, link, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("error on parse %s %s response: %s", method, link, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPI(t *testing.T) {
+	q, err := queue.Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	h := api{queue: q, searcher: testSearcher{}, dst: "/data"}.routes()
+
+	var res jobsResponse
+	status := do(t, h, http.MethodPost, "/jobs", `{"product_ids":["a"],"search":{"TileIDs":["36UYA","36UXA"]}}`, &res)
+	if status != http.StatusCreated || len(res.Jobs) != 3 {
+		t.Fatalf("3 jobs should be created, got %d %+v", status, res)
+	}
+	if res.Jobs[1].ProductID != "uuid-36UYA" || res.Jobs[1].Identifier != "S2A_36UYA" || res.Jobs[1].Dst != "/data" {
+		t.Errorf("unexpected job from search %+v", res.Jobs[1])
+	}
+	if status = do(t, h, http.MethodPost, "/jobs", `{}`, nil); status != http.StatusBadRequest {
+		t.Errorf("status should be 400, but is %d", status)
+	}
+	for _, dst := range []string{"/etc", "../data", "tiles/../../etc"} {
+		if status = do(t, h, http.MethodPost, "/jobs", `{"product_ids":["b"],"dst":"`+dst+`"}`, nil); status != http.StatusBadRequest {
+			t.Errorf("status should be 400 for dst %s, but is %d", dst, status)
+		}
+	}
+	var sub jobsResponse
+	if status = do(t, h, http.MethodPost, "/jobs", `{"product_ids":["b"],"dst":"tiles/36UYA"}`, &sub); status != http.StatusCreated || sub.Jobs[0].Dst != filepath.Join("/data", "tiles/36UYA") {
+		t.Errorf("job should be created in subdirectory, got %d %+v", status, sub)
+	}
+	q.Cancel(sub.Jobs[0].ID)
+
+	var job queue.Job
+	if status = do(t, h, http.MethodDelete, "/jobs/"+res.Jobs[0].ID, "", &job); status != http.StatusOK || job.Status != queue.StatusCanceled {
+		t.Errorf("job should be canceled, got %d %s", status, job.Status)
+	}
+	if status = do(t, h, http.MethodDelete, "/jobs/"+res.Jobs[0].ID, "", nil); status != http.StatusConflict {
+		t.Errorf("status should be 409, but is %d", status)
+	}
+	if status = do(t, h, http.MethodPost, "/jobs/"+res.Jobs[0].ID+"/retry", "", &job); status != http.StatusOK || job.Status != queue.StatusPending {
+		t.Errorf("job should be pending, got %d %s", status, job.Status)
+	}
+	if status = do(t, h, http.MethodGet, "/jobs/missing", "", nil); status != http.StatusNotFound {
+		t.Errorf("status should be 404, but is %d", status)
+	}
+
+	res = jobsResponse{}
+	if do(t, h, http.MethodGet, "/jobs?status=pending", "", &res); len(res.Jobs) != 3 {
+		t.Errorf("should be 3 pending jobs, but got %d", len(res.Jobs))
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	q, err := queue.Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	h := api{queue: q, searcher: testSearcher{}, dst: "/data", token: "secret"}.routes()
+
+	for header, expected := range map[string]int{"": http.StatusUnauthorized, "Bearer wrong": http.StatusUnauthorized, "Bearer secret": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != expected {
+			t.Errorf("status should be %d for %q, but is %d", expected, header, rec.Code)
+		}
+	}
+	if status := do(t, h, http.MethodGet, "/healthz", "", nil); status != http.StatusOK {
+		t.Errorf("health check should not require token, got %d", status)
+	}
+}
+
+func TestAPIWatches(t *testing.T) {
+	dir := t.TempDir()
+	q, err := queue.Open(filepath.Join(dir, "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	store, err := scheduler.OpenFileStore(filepath.Join(dir, "seen.json"), 0)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	watches := []scheduler.Watch{{Name: "tiles", Schedule: "@daily", Search: scheduler.Search{TileIDs: []string{"36UYA"}}}}
+	sched, err := scheduler.New(testSearcher{}, store, watches, scheduler.WithHandler(scheduler.EnqueueHandler(q, "/data")))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	h := api{queue: q, searcher: testSearcher{}, dst: "/data", scheduler: sched, watches: watches}.routes()
+
+	var res map[string]int
+	if status := do(t, h, http.MethodPost, "/watches/tiles/run", "", &res); status != http.StatusOK || res["new"] != 1 {
+		t.Errorf("watch should find 1 new product, got %d %v", status, res)
+	}
+	if status := do(t, h, http.MethodPost, "/watches/tiles/run", "", &res); status != http.StatusOK || res["new"] != 0 {
+		t.Errorf("watch should find no new products, got %d %v", status, res)
+	}
+	if status := do(t, h, http.MethodPost, "/watches/missing/run", "", nil); status != http.StatusNotFound {
+		t.Errorf("status should be 404, but is %d", status)
+	}
+	if jobs := q.List(); len(jobs) != 1 || jobs[0].Source != "tiles" {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+}
@@ -0,0 +1,166 @@
+// Command go-sentinel-daemon downloads products queued via REST API, see api.go for endpoints.
+// The queue is persisted to disk, so restarted daemon resumes its work.
+//
+// Credentials are taken from -credentials file, SENTINEL_CREDENTIALS or ~/.netrc, in this order.
+// The API listens on localhost by default, set SENTINEL_API_TOKEN to require bearer token
+// before exposing it on other addresses.
+//
+//	SENTINEL_API_TOKEN=secret SENTINEL_CREDENTIALS=user:password go-sentinel-daemon -addr :8080 -queue /var/lib/sentinel/queue.json -dst /data
+//
+// New products found by saved searches and finished downloads are reported to -notify-url and
+// webhooks of saved searches (signed with SENTINEL_WEBHOOK_SECRET if set), -notify-command and -spool sinks.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	sentinel_engine "github.com/therox/go-sentinel/backend/sentinel"
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/notify"
+	"github.com/therox/go-sentinel/queue"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/scheduler"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "HTTP API listen address")
+	queuePath := flag.String("queue", "queue.json", "job queue file")
+	dst := flag.String("dst", "downloads", "download directory, jobs may only choose its subdirectories")
+	baseURL := flag.String("base-url", "", "hub root URL, Copernicus Open Access Hub if empty")
+	workers := flag.Int("workers", 2, "concurrent downloads")
+	attempts := flag.Int("attempts", 5, "download attempts before job fails")
+	backoff := flag.Duration("backoff", time.Minute, "delay before second download attempt, doubled for next ones")
+	ltaPoll := flag.Duration("lta-poll", 10*time.Minute, "interval of checking products retrieved from long-term archive")
+	ltaTimeout := flag.Duration("lta-timeout", 48*time.Hour, "maximum wait for retrieval from long-term archive")
+	httpTimeout := flag.Duration("timeout", 60*time.Minute, "timeout of a single download")
+	watchesPath := flag.String("watches", "", "YAML or JSON file of saved searches run on schedule")
+	credentialsPath := flag.String("credentials", "", "JSON or YAML file of credentials per hub host")
+	seenPath := flag.String("seen", "seen.json", "file of products already found by saved searches")
+	notifyURL := flag.String("notify-url", "", "webhook URL notified of new products and finished downloads")
+	notifyCommand := flag.String("notify-command", "", "command run with event JSON on stdin for each event")
+	spoolDir := flag.String("spool", "", "directory event JSON files are dropped to")
+	layoutTemplate := flag.String("layout", "", "path of products in download directory, e.g. {platform}/{tile}/{year}/{month}/{identifier}.zip")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// per host file entries take precedence over environment credentials used for any host
+	providers := make([]credentials.Provider, 0)
+	if *credentialsPath != "" {
+		providers = append(providers, credentials.File(*credentialsPath))
+	}
+	auth := credentials.Cache(credentials.Chain(append(providers, credentials.FromEnv(), credentials.DefaultNetrc())...))
+
+	limiter := quota.New(quota.WithConcurrentDownloads(*workers))
+	searcherOpts := []sentinel.SearcherOption{sentinel.WithCredentials(auth), sentinel.WithLimiter(limiter), sentinel.WithLogger(logger)}
+	engineOpts := []sentinel_engine.Option{sentinel_engine.WithCredentials(auth), sentinel_engine.WithLimiter(limiter), sentinel_engine.WithLogger(logger)}
+	if *baseURL != "" {
+		searcherOpts = append(searcherOpts, sentinel.WithBaseURL(*baseURL))
+		engineOpts = append(engineOpts, sentinel_engine.WithBaseURL(*baseURL))
+	}
+	if *layoutTemplate != "" {
+		t, err := layout.Parse(*layoutTemplate)
+		if err != nil {
+			logger.Error("Error parsing layout", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		engineOpts = append(engineOpts, sentinel_engine.WithLayout(t))
+	}
+	searcher := sentinel.NewSentinelSearcher("", "", searcherOpts...)
+	engine := sentinel_engine.NewSentinelEngine("", "", *httpTimeout, engineOpts...)
+	client, err := sentinel.NewClient(searcher, engine)
+	if err != nil {
+		logger.Error("Error creating client", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	q, err := queue.Open(*queuePath)
+	if err != nil {
+		logger.Error("Error opening queue", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	watches := make([]scheduler.Watch, 0)
+	if *watchesPath != "" {
+		if watches, err = scheduler.LoadWatches(*watchesPath); err != nil {
+			logger.Error("Error loading watches", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	secret := os.Getenv("SENTINEL_WEBHOOK_SECRET")
+	sinks := notify.WatchWebhooks(watches, secret)
+	if *notifyURL != "" {
+		sinks = append(sinks, notify.Webhook{URL: *notifyURL, Secret: secret})
+	}
+	if *notifyCommand != "" {
+		sinks = append(sinks, notify.Command{Path: *notifyCommand})
+	}
+	if *spoolDir != "" {
+		sinks = append(sinks, notify.Spool{Dir: *spoolDir})
+	}
+	dispatcher := notify.NewDispatcher(sinks, notify.WithLogger(logger))
+
+	worker := queue.NewWorker(q, client,
+		queue.WithWorkers(*workers),
+		queue.WithRetries(*attempts, *backoff),
+		queue.WithLTAPolling(*ltaPoll, *ltaTimeout),
+		queue.WithWorkerLogger(logger),
+		queue.WithFinishHook(notify.JobHook(dispatcher)),
+	)
+
+	// products seen more than a year ago are not returned by watches anymore
+	store, err := scheduler.OpenFileStore(*seenPath, 365*24*time.Hour)
+	if err != nil {
+		logger.Error("Error opening seen store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	sched, err := scheduler.New(client.Searcher, store, watches,
+		scheduler.WithHandler(scheduler.EnqueueHandler(q, *dst)),
+		scheduler.WithHandler(notify.WatchHandler(dispatcher)),
+		scheduler.WithLogger(logger),
+	)
+	if err != nil {
+		logger.Error("Error creating scheduler", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go sched.Run(ctx)
+	go dispatcher.Run(ctx)
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: api{queue: q, searcher: client.Searcher, dst: *dst, scheduler: sched, watches: watches, token: os.Getenv("SENTINEL_API_TOKEN")}.routes(),
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(done)
+	}()
+
+	logger.Info("daemon started", slog.String("addr", *addr), slog.String("queue", *queuePath))
+	if err = srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Error serving API", slog.String("error", err.Error()))
+		stop()
+	}
+	<-done
+	logger.Info("daemon stopped")
+}
@@ -0,0 +1,151 @@
+// Package credentials provides hub credentials resolved per host: static values, environment
+// variables, netrc, JSON/YAML config files and keyring-style secret stores. Providers are asked
+// on each request, so a single chain serves searchers and engines of several hubs.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by providers having no credentials for the host
+var ErrNotFound = errors.New("credentials not found")
+
+// Credentials are basic auth user and password
+type Credentials struct {
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// Provider returns credentials for host, e.g. apihub.copernicus.eu. It returns ErrNotFound
+// (possibly wrapped) if it has none
+type Provider interface {
+	Credentials(ctx context.Context, host string) (Credentials, error)
+}
+
+// ProviderFunc is a function implementing Provider
+type ProviderFunc func(ctx context.Context, host string) (Credentials, error)
+
+func (f ProviderFunc) Credentials(ctx context.Context, host string) (Credentials, error) {
+	return f(ctx, host)
+}
+
+// Static returns the same credentials for any host
+func Static(user string, password string) Provider {
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		return Credentials{User: user, Password: password}, nil
+	})
+}
+
+// Env returns credentials from userVar and passwordVar environment variables for any host.
+// Variables are read on each call
+func Env(userVar string, passwordVar string) Provider {
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		user, password := os.Getenv(userVar), os.Getenv(passwordVar)
+		if user == "" || password == "" {
+			return Credentials{}, fmt.Errorf("%w in %s and %s", ErrNotFound, userVar, passwordVar)
+		}
+		return Credentials{User: user, Password: password}, nil
+	})
+}
+
+// FromEnv returns credentials from SENTINEL_CREDENTIALS as "user:password" or from
+// SENTINEL_USER and SENTINEL_PASSWORD environment variables
+func FromEnv() Provider {
+	env := Env("SENTINEL_USER", "SENTINEL_PASSWORD")
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		if user, password, ok := strings.Cut(os.Getenv("SENTINEL_CREDENTIALS"), ":"); ok && user != "" {
+			return Credentials{User: user, Password: password}, nil
+		}
+		return env.Credentials(ctx, host)
+	})
+}
+
+// Chain returns credentials of the first provider having them. Errors other than ErrNotFound
+// stop the chain
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		for _, p := range providers {
+			c, err := p.Credentials(ctx, host)
+			if err == nil {
+				return c, nil
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return Credentials{}, err
+			}
+		}
+		return Credentials{}, fmt.Errorf("%w for %s", ErrNotFound, host)
+	})
+}
+
+// Invalidator is implemented by providers caching credentials, e.g. Cached
+type Invalidator interface {
+	// Invalidate forgets credentials of host, they are asked again on the next request
+	Invalidate(host string)
+}
+
+// Cached remembers credentials returned by provider per host. Failures are not cached
+type Cached struct {
+	p     Provider
+	mu    sync.Mutex
+	cache map[string]Credentials
+}
+
+// Cache returns p caching credentials per host. Use Transport to forget them when the hub rejects them
+func Cache(p Provider) *Cached {
+	return &Cached{p: p, cache: make(map[string]Credentials)}
+}
+
+func (c *Cached) Credentials(ctx context.Context, host string) (Credentials, error) {
+	c.mu.Lock()
+	cred, ok := c.cache[host]
+	c.mu.Unlock()
+	if ok {
+		return cred, nil
+	}
+	cred, err := c.p.Credentials(ctx, host)
+	if err != nil {
+		return cred, err
+	}
+	c.mu.Lock()
+	c.cache[host] = cred
+	c.mu.Unlock()
+	return cred, nil
+}
+
+func (c *Cached) Invalidate(host string) {
+	c.mu.Lock()
+	delete(c.cache, host)
+	c.mu.Unlock()
+}
+
+// Transport returns rt invalidating credentials of p for the host responding 401 Unauthorized,
+// e.g. after password change. rt is returned as is if p does not implement Invalidator
+func Transport(p Provider, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	inv, ok := p.(Invalidator)
+	if !ok {
+		return rt
+	}
+	return transport{inv: inv, rt: rt}
+}
+
+type transport struct {
+	inv Invalidator
+	rt  http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.inv.Invalidate(req.URL.Hostname())
+	}
+	return resp, err
+}
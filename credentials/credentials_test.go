@@ -0,0 +1,123 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetrc(t *testing.T) {
+	p := Netrc("testdata/netrc")
+	cases := map[string]Credentials{
+		"apihub.copernicus.eu": {User: "alice", Password: "s3cret"},
+		"colhub.copernicus.eu": {User: "bob", Password: "hunter2"},
+		"ignored.example.org":  {User: "anonymous", Password: "guest"},
+		"scihub.copernicus.eu": {User: "anonymous", Password: "guest"},
+	}
+	for host, want := range cases {
+		c, err := p.Credentials(context.Background(), host)
+		if err != nil {
+			t.Fatalf("%s: error should be nil, but is %s", host, err)
+		}
+		if c != want {
+			t.Errorf("%s: credentials should be %v, but are %v", host, want, c)
+		}
+	}
+	if _, err := Netrc("testdata/missing").Credentials(context.Background(), "apihub.copernicus.eu"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing netrc should give ErrNotFound, but gives %v", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	p := File("testdata/credentials.yaml")
+	c, err := p.Credentials(context.Background(), "apihub.copernicus.eu")
+	if err != nil || c.User != "alice" || c.Password != "s3cret" {
+		t.Errorf("unexpected credentials %v, error %v", c, err)
+	}
+	if _, err = p.Credentials(context.Background(), "colhub.copernicus.eu"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("default entry with empty password env should give ErrNotFound, but gives %v", err)
+	}
+	t.Setenv("TEST_SENTINEL_PASSWORD", "pw")
+	c, err = p.Credentials(context.Background(), "colhub.copernicus.eu")
+	if err != nil || c.User != "carol" || c.Password != "pw" {
+		t.Errorf("unexpected credentials %v, error %v", c, err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Setenv("SENTINEL_CREDENTIALS", "")
+	t.Setenv("SENTINEL_USER", "")
+	t.Setenv("SENTINEL_PASSWORD", "")
+	calls := 0
+	store := SecretStoreFunc(func(ctx context.Context, service string, account string) (string, error) {
+		calls++
+		if service == "colhub.copernicus.eu" && account == "dave" {
+			return "keyring", nil
+		}
+		return "", ErrNotFound
+	})
+	p := Cache(Chain(FromEnv(), Netrc("testdata/missing"), Keyring(store, "dave"), File("testdata/credentials.yaml")))
+
+	c, err := p.Credentials(context.Background(), "colhub.copernicus.eu")
+	if err != nil || c.User != "dave" || c.Password != "keyring" {
+		t.Errorf("unexpected credentials %v, error %v", c, err)
+	}
+	p.Credentials(context.Background(), "colhub.copernicus.eu")
+	if calls != 1 {
+		t.Errorf("secret store should be asked once, but was asked %d times", calls)
+	}
+	c, err = p.Credentials(context.Background(), "apihub.copernicus.eu")
+	if err != nil || c.User != "alice" {
+		t.Errorf("unexpected credentials %v, error %v", c, err)
+	}
+
+	t.Setenv("SENTINEL_CREDENTIALS", "env:pass:word")
+	c, err = Chain(FromEnv(), File("testdata/credentials.yaml")).Credentials(context.Background(), "apihub.copernicus.eu")
+	if err != nil || c.User != "env" || c.Password != "pass:word" {
+		t.Errorf("unexpected credentials %v, error %v", c, err)
+	}
+
+	failing := ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		return Credentials{}, errors.New("keyring locked")
+	})
+	if _, err = Chain(failing, Static("u", "p")).Credentials(context.Background(), "h"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("chain should stop on provider failure, but error is %v", err)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	password, accepted := "old", "old"
+	p := Cache(ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		return Credentials{User: "u", Password: password}, nil
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pw, _ := r.BasicAuth(); pw != accepted {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	c := &http.Client{Transport: Transport(p, nil)}
+
+	get := func() int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		cred, _ := p.Credentials(context.Background(), req.URL.Hostname())
+		req.SetBasicAuth(cred.User, cred.Password)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("error should be nil, but is %s", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	get()
+	// password changed, cached one is used until the hub rejects it
+	password, accepted = "new", "new"
+	if status := get(); status != http.StatusUnauthorized {
+		t.Errorf("cached credentials should be used, but status is %d", status)
+	}
+	if status := get(); status != http.StatusOK {
+		t.Errorf("rejected credentials should be asked again, but status is %d", status)
+	}
+}
@@ -0,0 +1,85 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEntry is credentials entry of config file. PasswordEnv names environment variable
+// holding the password, so the file may be shared without secrets
+type FileEntry struct {
+	User        string `json:"user" yaml:"user"`
+	Password    string `json:"password,omitempty" yaml:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty" yaml:"password_env,omitempty"`
+}
+
+// FileConfig is a credentials config file
+//
+//	default:
+//	  user: alice
+//	  password_env: SENTINEL_PASSWORD
+//	hosts:
+//	  apihub.copernicus.eu:
+//	    user: bob
+//	    password: secret
+type FileConfig struct {
+	Default *FileEntry           `json:"default,omitempty" yaml:"default,omitempty"`
+	Hosts   map[string]FileEntry `json:"hosts" yaml:"hosts"`
+}
+
+// File returns credentials from JSON or YAML (by .yaml or .yml extension) config file, see FileConfig.
+// The file is read on first use
+func File(filePath string) Provider {
+	var (
+		once    sync.Once
+		cfg     FileConfig
+		loadErr error
+	)
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		once.Do(func() {
+			cfg, loadErr = readFile(filePath)
+		})
+		if loadErr != nil {
+			return Credentials{}, loadErr
+		}
+		entry, ok := cfg.Hosts[host]
+		if !ok {
+			if cfg.Default == nil {
+				return Credentials{}, fmt.Errorf("%w for %s in %s", ErrNotFound, host, filePath)
+			}
+			entry = *cfg.Default
+		}
+		c := Credentials{User: entry.User, Password: entry.Password}
+		if entry.PasswordEnv != "" {
+			if c.Password = os.Getenv(entry.PasswordEnv); c.Password == "" {
+				return Credentials{}, fmt.Errorf("%w: %s is empty", ErrNotFound, entry.PasswordEnv)
+			}
+		}
+		return c, nil
+	})
+}
+
+func readFile(filePath string) (FileConfig, error) {
+	var cfg FileConfig
+	bs, err := os.ReadFile(filePath)
+	if err != nil {
+		return cfg, fmt.Errorf("error on read credentials: %s", err)
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bs, &cfg)
+	default:
+		err = json.Unmarshal(bs, &cfg)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error on parse credentials %s: %s", filePath, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,35 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SecretStore is a keyring-style secret storage, e.g. an adapter to OS keyring, Vault or a
+// cloud secret manager. Secret returns ErrNotFound (possibly wrapped) for missing secrets
+type SecretStore interface {
+	Secret(ctx context.Context, service string, account string) (string, error)
+}
+
+// SecretStoreFunc is a function implementing SecretStore
+type SecretStoreFunc func(ctx context.Context, service string, account string) (string, error)
+
+func (f SecretStoreFunc) Secret(ctx context.Context, service string, account string) (string, error) {
+	return f(ctx, service, account)
+}
+
+// Keyring returns user with password stored in store for service named as host. Store is asked
+// on each call, wrap provider with Cache if it is expensive
+func Keyring(store SecretStore, user string) Provider {
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		password, err := store.Secret(ctx, host, user)
+		if errors.Is(err, ErrNotFound) {
+			return Credentials{}, err
+		}
+		if err != nil {
+			return Credentials{}, fmt.Errorf("error on get secret of %s@%s: %s", user, host, err)
+		}
+		return Credentials{User: user, Password: password}, nil
+	})
+}
@@ -0,0 +1,131 @@
+package credentials
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Netrc returns credentials of machine entries of netrc file at path, falling back to its
+// default entry. The file is read on first use
+func Netrc(path string) Provider {
+	var (
+		once     sync.Once
+		machines map[string]Credentials
+		loadErr  error
+	)
+	return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+		once.Do(func() {
+			machines, loadErr = readNetrc(path)
+		})
+		if loadErr != nil {
+			return Credentials{}, loadErr
+		}
+		if c, ok := machines[host]; ok {
+			return c, nil
+		}
+		if c, ok := machines[""]; ok {
+			return c, nil
+		}
+		return Credentials{}, fmt.Errorf("%w for %s in %s", ErrNotFound, host, path)
+	})
+}
+
+// DefaultNetrc returns Netrc of file in NETRC environment variable or ~/.netrc.
+// Missing file means no credentials
+func DefaultNetrc() Provider {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ProviderFunc(func(ctx context.Context, host string) (Credentials, error) {
+				return Credentials{}, fmt.Errorf("%w: no home directory for .netrc", ErrNotFound)
+			})
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	return Netrc(path)
+}
+
+// readNetrc parses netrc file, default entry is stored with empty key
+func readNetrc(path string) (map[string]Credentials, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s does not exist", ErrNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on open netrc: %s", err)
+	}
+	defer f.Close()
+
+	machines := make(map[string]Credentials)
+	var (
+		machine string
+		current *Credentials
+	)
+	flush := func() {
+		if current != nil {
+			if _, ok := machines[machine]; !ok {
+				machines[machine] = *current
+			}
+		}
+		current = nil
+	}
+
+	sc := bufio.NewScanner(f)
+	inMacro := false
+	for sc.Scan() {
+		line := sc.Text()
+		// macro definitions run until an empty line
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			value := func() string {
+				if i+1 < len(fields) {
+					i++
+					return fields[i]
+				}
+				return ""
+			}
+			switch fields[i] {
+			case "machine":
+				flush()
+				machine = value()
+				current = &Credentials{}
+			case "default":
+				flush()
+				machine = ""
+				current = &Credentials{}
+			case "login":
+				if v := value(); current != nil {
+					current.User = v
+				}
+			case "password":
+				if v := value(); current != nil {
+					current.Password = v
+				}
+			case "account":
+				value()
+			case "macdef":
+				flush()
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	flush()
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("error on read netrc: %s", err)
+	}
+	return machines, nil
+}
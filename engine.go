@@ -1,8 +1,30 @@
 package sentinel
 
+import "github.com/therox/go-sentinel/storage"
+
 // Main engine interface. It must provide functions like search, download file from backend
 type dlEngine interface {
 	// SearchDataset(datasetName string)
 	Download(productID string, dst string) (string, error)
 	IsOnline(productID string) (bool, error)
 }
+
+// Optional engine capability to stream product to storage sink
+type sinkDownloader interface {
+	DownloadTo(productID string, sink storage.Sink) (string, error)
+}
+
+// Optional engine capability to download product using entry metadata, e.g. for path layout
+type entryDownloader interface {
+	DownloadEntry(entry QueryEntryResponse, dst string) (string, error)
+}
+
+// Optional engine capability to verify downloaded file against hub checksums
+type productVerifier interface {
+	Verify(productID string, filePath string) error
+}
+
+// Optional engine capability to fetch product metadata by ID
+type productGetter interface {
+	GetProduct(productID string) (QueryEntryResponse, error)
+}
@@ -3,24 +3,21 @@ package main
 import (
 	"fmt"
 	"log"
-	"os"
-	"strings"
 	"time"
 
 	sentinel "github.com/therox/go-sentinel"
 	sentinel_engine "github.com/therox/go-sentinel/backend/sentinel"
+	"github.com/therox/go-sentinel/credentials"
 )
 
 func main() {
 
-	credentials := strings.Split(os.Getenv("SENTINEL_CREDENTIALS"), ":")
-	if len(credentials) < 2 {
-		log.Fatalf("Please provide SENTINEL_CREDENTIALS!")
-	}
+	// SENTINEL_CREDENTIALS=user:password or machine entry of the hub host in ~/.netrc
+	auth := credentials.Chain(credentials.FromEnv(), credentials.DefaultNetrc())
 
-	searcher := sentinel.NewSentinelSearcher(credentials[0], credentials[1])
+	searcher := sentinel.NewSentinelSearcher("", "", sentinel.WithCredentials(auth))
 
-	dlEngine := sentinel_engine.NewSentinelEngine(credentials[0], credentials[1], 60*time.Minute)
+	dlEngine := sentinel_engine.NewSentinelEngine("", "", 60*time.Minute, sentinel_engine.WithCredentials(auth))
 
 	client, err := sentinel.NewClient(searcher, dlEngine)
 	if err != nil {
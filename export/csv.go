@@ -0,0 +1,70 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+// WriteCSV writes entries as CSV with header, footprint is written as WKT in the last column
+func WriteCSV(w io.Writer, entries []sentinel.QueryEntryResponse) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(Columns)+1)
+	for _, c := range Columns {
+		header = append(header, c.Name)
+	}
+	header = append(header, FootprintColumn)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("error on write CSV: %s", err)
+	}
+
+	for i := range entries {
+		record := make([]string, 0, len(header))
+		for _, c := range Columns {
+			record = append(record, c.Get(&entries[i]))
+		}
+		record = append(record, entries[i].Footprint)
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("error on write CSV: %s", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error on write CSV: %s", err)
+	}
+	return nil
+}
+
+// ReadCSV reads entries written by WriteCSV. Columns are matched by header names, so CSV
+// edited in spreadsheet software with columns removed or reordered can be read too.
+func ReadCSV(r io.Reader) ([]sentinel.QueryEntryResponse, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error on read CSV header: %s", err)
+	}
+
+	entries := make([]sentinel.QueryEntryResponse, 0)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error on read CSV: %s", err)
+		}
+		properties := make(map[string]string, len(header))
+		for i := range header {
+			if i < len(record) {
+				properties[header[i]] = record[i]
+			}
+		}
+		e, err := FromProperties(properties, properties[FootprintColumn])
+		if err != nil {
+			return nil, fmt.Errorf("error on read CSV line %d: %s", len(entries)+2, err)
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+func testEntries() []sentinel.QueryEntryResponse {
+	e1 := sentinel.QueryEntryResponse{
+		ID:                   "uuid-1",
+		Title:                "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407",
+		Online:               true,
+		ContentLength:        1073741824,
+		Footprint:            "POLYGON((35.82 50.51,37.36 50.47,37.27 49.48,35.76 49.53,35.82 50.51))",
+		BeginPosition:        time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC),
+		RelativeOrbitNumber:  21,
+		CloudCoverPercentage: 12.345,
+	}
+	e1.UUID = "uuid-1"
+	e1.Identifier = e1.Title
+	e1.TileId = "36UYA"
+	e2 := sentinel.QueryEntryResponse{
+		ID:         "uuid-2",
+		UUID:       "uuid-2",
+		Identifier: "S1A_IW_GRDH_1SDV_20220101T035512_20220101T035537_041261_04E787_5C9A",
+		Footprint:  "MULTIPOLYGON(((179 10,180 10,180 11,179 11,179 10)),((-180 10,-179 10,-179 11,-180 10)))",
+	}
+	return []sentinel.QueryEntryResponse{e1, e2}
+}
+
+// comparable drops fields not exported, e.g. attributes map filled on reading
+func comparable(entries []sentinel.QueryEntryResponse) []map[string]string {
+	res := make([]map[string]string, len(entries))
+	for i := range entries {
+		res[i] = Properties(&entries[i])
+		res[i][FootprintColumn] = entries[i].Footprint
+	}
+	return res
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := map[string]struct {
+		write func(*bytes.Buffer, []sentinel.QueryEntryResponse) error
+		read  func(*bytes.Buffer) ([]sentinel.QueryEntryResponse, error)
+	}{
+		"geojson": {
+			func(b *bytes.Buffer, e []sentinel.QueryEntryResponse) error { return WriteGeoJSON(b, e) },
+			func(b *bytes.Buffer) ([]sentinel.QueryEntryResponse, error) { return ReadGeoJSON(b) }},
+		"csv": {
+			func(b *bytes.Buffer, e []sentinel.QueryEntryResponse) error { return WriteCSV(b, e) },
+			func(b *bytes.Buffer) ([]sentinel.QueryEntryResponse, error) { return ReadCSV(b) }},
+		"kml": {
+			func(b *bytes.Buffer, e []sentinel.QueryEntryResponse) error { return WriteKML(b, "test", e) },
+			func(b *bytes.Buffer) ([]sentinel.QueryEntryResponse, error) { return ReadKML(b) }},
+	}
+	expected := comparable(testEntries())
+	for name, f := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := f.write(&buf, testEntries()); err != nil {
+				t.Fatalf("error should be nil, but is %s", err)
+			}
+			entries, err := f.read(&buf)
+			if err != nil {
+				t.Fatalf("error should be nil, but is %s", err)
+			}
+			if actual := comparable(entries); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("entries differ after round trip:\n%v\n%v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestParseWKT(t *testing.T) {
+	g, err := ParseWKT("geography'SRID=4326;POLYGON ((1 2, 3 4, 5 6, 1 2), (2 3, 3 3, 2 3))'")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if g.WKT() != "POLYGON((1 2,3 4,5 6,1 2),(2 3,3 3,2 3))" {
+		t.Errorf("unexpected WKT %s", g.WKT())
+	}
+	wkb, err := ParseWKB(g.WKB())
+	if err != nil || !reflect.DeepEqual(wkb, g) {
+		t.Errorf("geometry differs after WKB round trip: %v %v", wkb, err)
+	}
+	if _, err = ParseWKT("POINT(1 2)"); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+	if _, err = ParseWKT("POLYGON((1 2, 3"); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+}
@@ -0,0 +1,135 @@
+// Package export writes search results to formats used by GIS software and reads them back.
+package export
+
+import (
+	"strconv"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+// Kind is a column value type, used by formats having typed columns
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindInteger
+	KindReal
+	KindTime
+	KindBoolean
+)
+
+// Column is an exported entry field. Footprint is not a column, it is written as geometry.
+type Column struct {
+	Name string
+	Kind Kind
+	Get  func(e *sentinel.QueryEntryResponse) string
+	Set  func(e *sentinel.QueryEntryResponse, value string) error
+}
+
+func textColumn(name string, get func(e *sentinel.QueryEntryResponse) string) Column {
+	return Column{Name: name, Kind: KindText, Get: get}
+}
+
+func intColumn(name string, get func(e *sentinel.QueryEntryResponse) int) Column {
+	return Column{Name: name, Kind: KindInteger, Get: func(e *sentinel.QueryEntryResponse) string {
+		return strconv.Itoa(get(e))
+	}}
+}
+
+func realColumn(name string, get func(e *sentinel.QueryEntryResponse) float64) Column {
+	return Column{Name: name, Kind: KindReal, Get: func(e *sentinel.QueryEntryResponse) string {
+		return formatFloat(get(e))
+	}}
+}
+
+func timeColumn(name string, get func(e *sentinel.QueryEntryResponse) time.Time) Column {
+	return Column{Name: name, Kind: KindTime, Get: func(e *sentinel.QueryEntryResponse) string {
+		t := get(e)
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}}
+}
+
+// Columns are written in this order. Columns named after OpenSearch attributes are read back with SetAttribute.
+var Columns = []Column{
+	{Name: "id", Kind: KindText,
+		Get: func(e *sentinel.QueryEntryResponse) string { return e.ID },
+		Set: func(e *sentinel.QueryEntryResponse, v string) error { e.ID = v; return nil }},
+	textColumn("uuid", func(e *sentinel.QueryEntryResponse) string { return e.UUID }),
+	textColumn("identifier", func(e *sentinel.QueryEntryResponse) string { return e.Identifier }),
+	{Name: "title", Kind: KindText,
+		Get: func(e *sentinel.QueryEntryResponse) string { return e.Title },
+		Set: func(e *sentinel.QueryEntryResponse, v string) error { e.Title = v; return nil }},
+	textColumn("filename", func(e *sentinel.QueryEntryResponse) string { return e.FileName }),
+	textColumn("platformname", func(e *sentinel.QueryEntryResponse) string { return e.PlatformName }),
+	textColumn("platformserialidentifier", func(e *sentinel.QueryEntryResponse) string { return e.PlatformSerialIdentifier }),
+	textColumn("instrumentshortname", func(e *sentinel.QueryEntryResponse) string { return e.InstrumentShortName }),
+	textColumn("sensoroperationalmode", func(e *sentinel.QueryEntryResponse) string { return e.SensorOperationalMode }),
+	textColumn("producttype", func(e *sentinel.QueryEntryResponse) string { return e.ProductType }),
+	textColumn("processinglevel", func(e *sentinel.QueryEntryResponse) string { return e.ProcessingLevel }),
+	textColumn("processingbaseline", func(e *sentinel.QueryEntryResponse) string { return e.ProcessingBaseline }),
+	textColumn("tileid", func(e *sentinel.QueryEntryResponse) string { return e.TileId }),
+	intColumn("orbitnumber", func(e *sentinel.QueryEntryResponse) int { return e.OrbitNumber }),
+	intColumn("relativeorbitnumber", func(e *sentinel.QueryEntryResponse) int { return e.RelativeOrbitNumber }),
+	textColumn("orbitdirection", func(e *sentinel.QueryEntryResponse) string { return e.OrbitDirection }),
+	timeColumn("beginposition", func(e *sentinel.QueryEntryResponse) time.Time { return e.BeginPosition }),
+	timeColumn("endposition", func(e *sentinel.QueryEntryResponse) time.Time { return e.EndPosition }),
+	timeColumn("ingestiondate", func(e *sentinel.QueryEntryResponse) time.Time { return e.IngestionDate }),
+	realColumn("cloudcoverpercentage", func(e *sentinel.QueryEntryResponse) float64 { return e.CloudCoverPercentage }),
+	textColumn("size", func(e *sentinel.QueryEntryResponse) string { return e.Size }),
+	{Name: "contentlength", Kind: KindInteger,
+		Get: func(e *sentinel.QueryEntryResponse) string { return strconv.FormatInt(e.ContentLength, 10) },
+		Set: func(e *sentinel.QueryEntryResponse, v string) error {
+			var err error
+			e.ContentLength, err = strconv.ParseInt(v, 10, 64)
+			return err
+		}},
+	{Name: "online", Kind: KindBoolean,
+		Get: func(e *sentinel.QueryEntryResponse) string { return strconv.FormatBool(e.Online) },
+		Set: func(e *sentinel.QueryEntryResponse, v string) error {
+			var err error
+			e.Online, err = strconv.ParseBool(v)
+			return err
+		}},
+	{Name: "hub", Kind: KindText,
+		Get: func(e *sentinel.QueryEntryResponse) string { return e.Hub },
+		Set: func(e *sentinel.QueryEntryResponse, v string) error { e.Hub = v; return nil }},
+}
+
+// FootprintColumn is a name of WKT footprint column in formats without geometry type
+const FootprintColumn = "footprint"
+
+// setColumn sets entry field from exported value, empty values are skipped
+func setColumn(e *sentinel.QueryEntryResponse, c Column, value string) error {
+	if value == "" {
+		return nil
+	}
+	if c.Set != nil {
+		return c.Set(e, value)
+	}
+	return e.SetAttribute(c.Name, value)
+}
+
+// Properties returns exported column values of entry by column name
+func Properties(e *sentinel.QueryEntryResponse) map[string]string {
+	res := make(map[string]string, len(Columns))
+	for _, c := range Columns {
+		res[c.Name] = c.Get(e)
+	}
+	return res
+}
+
+// FromProperties restores entry from column values and WKT footprint. Unknown properties are ignored.
+func FromProperties(properties map[string]string, footprint string) (sentinel.QueryEntryResponse, error) {
+	var e sentinel.QueryEntryResponse
+	for _, c := range Columns {
+		if err := setColumn(&e, c, properties[c.Name]); err != nil {
+			return e, err
+		}
+	}
+	e.Footprint = footprint
+	return e, nil
+}
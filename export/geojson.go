@@ -0,0 +1,144 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func toGeoJSONGeometry(footprint string) (*geoJSONGeometry, error) {
+	if footprint == "" {
+		return nil, nil
+	}
+	g, err := ParseWKT(footprint)
+	if err != nil {
+		return nil, err
+	}
+	res := &geoJSONGeometry{Type: "Polygon"}
+	var coordinates interface{} = g.Polygons[0]
+	if g.IsMulti {
+		res.Type = "MultiPolygon"
+		coordinates = g.Polygons
+	}
+	res.Coordinates, err = json.Marshal(coordinates)
+	return res, err
+}
+
+func fromGeoJSONGeometry(gj *geoJSONGeometry) (string, error) {
+	if gj == nil {
+		return "", nil
+	}
+	var g Geometry
+	switch gj.Type {
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(gj.Coordinates, &p); err != nil {
+			return "", err
+		}
+		g.Polygons = []Polygon{p}
+	case "MultiPolygon":
+		g.IsMulti = true
+		if err := json.Unmarshal(gj.Coordinates, &g.Polygons); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported geometry type %s", gj.Type)
+	}
+	return g.WKT(), nil
+}
+
+// typedValue converts column value to JSON number or boolean where applicable
+func typedValue(c Column, value string) interface{} {
+	switch c.Kind {
+	case KindInteger, KindReal:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case KindBoolean:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// WriteGeoJSON writes entries as GeoJSON FeatureCollection with footprints as geometries
+func WriteGeoJSON(w io.Writer, entries []sentinel.QueryEntryResponse) error {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoJSONFeature, len(entries)),
+	}
+	for i := range entries {
+		e := &entries[i]
+		geometry, err := toGeoJSONGeometry(e.Footprint)
+		if err != nil {
+			return fmt.Errorf("error on convert footprint of %s: %s", e.Identifier, err)
+		}
+		properties := make(map[string]interface{}, len(Columns))
+		for _, c := range Columns {
+			if v := c.Get(e); v != "" {
+				properties[c.Name] = typedValue(c, v)
+			}
+		}
+		fc.Features[i] = geoJSONFeature{
+			Type:       "Feature",
+			ID:         e.ID,
+			Geometry:   geometry,
+			Properties: properties,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fc); err != nil {
+		return fmt.Errorf("error on write GeoJSON: %s", err)
+	}
+	return nil
+}
+
+// ReadGeoJSON reads entries written by WriteGeoJSON
+func ReadGeoJSON(r io.Reader) ([]sentinel.QueryEntryResponse, error) {
+	var fc geoJSONFeatureCollection
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("error on read GeoJSON: %s", err)
+	}
+	entries := make([]sentinel.QueryEntryResponse, len(fc.Features))
+	for i, f := range fc.Features {
+		footprint, err := fromGeoJSONGeometry(f.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("error on read geometry of feature %d: %s", i, err)
+		}
+		properties := make(map[string]string, len(f.Properties))
+		for k, v := range f.Properties {
+			if v != nil {
+				properties[k] = fmt.Sprint(v)
+			}
+		}
+		if entries[i], err = FromProperties(properties, footprint); err != nil {
+			return nil, fmt.Errorf("error on read properties of feature %d: %s", i, err)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,304 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Ring is a closed line of lon/lat points
+type Ring [][2]float64
+
+// Polygon is an outer ring followed by holes
+type Polygon []Ring
+
+// Geometry is a product footprint, either single polygon or multipolygon
+type Geometry struct {
+	Polygons []Polygon
+	IsMulti  bool
+}
+
+// ParseWKT parses POLYGON or MULTIPOLYGON footprint. EWKT SRID prefix and geography'...' wrapper
+// of OData services are ignored.
+func ParseWKT(wkt string) (Geometry, error) {
+	var g Geometry
+	s := strings.TrimSpace(wkt)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "geography'"), "'")
+	if i := strings.Index(s, ";"); i >= 0 && strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		s = s[i+1:]
+	}
+	upper := strings.ToUpper(s)
+	p := &wktParser{}
+	var err error
+	switch {
+	case strings.HasPrefix(upper, "MULTIPOLYGON"):
+		g.IsMulti = true
+		p.s = s[len("MULTIPOLYGON"):]
+		g.Polygons, err = p.parseMultiPolygon()
+	case strings.HasPrefix(upper, "POLYGON"):
+		p.s = s[len("POLYGON"):]
+		var polygon Polygon
+		polygon, err = p.parsePolygon()
+		g.Polygons = []Polygon{polygon}
+	default:
+		return g, fmt.Errorf("unsupported geometry: %.20s", wkt)
+	}
+	if err != nil {
+		return g, fmt.Errorf("error on parse WKT: %s", err)
+	}
+	return g, nil
+}
+
+type wktParser struct {
+	s string
+}
+
+func (p *wktParser) skipSpaces() {
+	p.s = strings.TrimLeft(p.s, " \t\r\n")
+}
+
+func (p *wktParser) expect(c byte) error {
+	p.skipSpaces()
+	if len(p.s) == 0 || p.s[0] != c {
+		return fmt.Errorf("expected %q at %.20q", c, p.s)
+	}
+	p.s = p.s[1:]
+	return nil
+}
+
+// next consumes separator and reports whether list continues
+func (p *wktParser) next() (bool, error) {
+	p.skipSpaces()
+	if len(p.s) > 0 && p.s[0] == ',' {
+		p.s = p.s[1:]
+		return true, nil
+	}
+	return false, p.expect(')')
+}
+
+func (p *wktParser) parseMultiPolygon() ([]Polygon, error) {
+	res := make([]Polygon, 0)
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	for {
+		polygon, err := p.parsePolygon()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, polygon)
+		if isNext, err := p.next(); err != nil || !isNext {
+			return res, err
+		}
+	}
+}
+
+func (p *wktParser) parsePolygon() (Polygon, error) {
+	res := make(Polygon, 0)
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	for {
+		ring, err := p.parseRing()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ring)
+		if isNext, err := p.next(); err != nil || !isNext {
+			return res, err
+		}
+	}
+}
+
+func (p *wktParser) parseRing() (Ring, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	end := strings.IndexByte(p.s, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("unclosed ring")
+	}
+	points := strings.Split(p.s[:end], ",")
+	p.s = p.s[end+1:]
+
+	res := make(Ring, len(points))
+	for i := range points {
+		xy := strings.Fields(points[i])
+		if len(xy) < 2 {
+			return nil, fmt.Errorf("invalid point %q", points[i])
+		}
+		for j := 0; j < 2; j++ {
+			v, err := strconv.ParseFloat(xy[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate %q", xy[j])
+			}
+			res[i][j] = v
+		}
+	}
+	return res, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (r Ring) wkt() string {
+	points := make([]string, len(r))
+	for i := range r {
+		points[i] = formatFloat(r[i][0]) + " " + formatFloat(r[i][1])
+	}
+	return "(" + strings.Join(points, ",") + ")"
+}
+
+func (p Polygon) wkt() string {
+	rings := make([]string, len(p))
+	for i := range p {
+		rings[i] = p[i].wkt()
+	}
+	return "(" + strings.Join(rings, ",") + ")"
+}
+
+// WKT returns geometry in WKT form
+func (g Geometry) WKT() string {
+	if len(g.Polygons) == 0 {
+		return ""
+	}
+	if !g.IsMulti {
+		return "POLYGON" + g.Polygons[0].wkt()
+	}
+	polygons := make([]string, len(g.Polygons))
+	for i := range g.Polygons {
+		polygons[i] = g.Polygons[i].wkt()
+	}
+	return "MULTIPOLYGON(" + strings.Join(polygons, ",") + ")"
+}
+
+// Envelope returns min lon, min lat, max lon, max lat
+func (g Geometry) Envelope() [4]float64 {
+	env := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range g.Polygons {
+		for _, r := range p {
+			for _, pt := range r {
+				env[0] = math.Min(env[0], pt[0])
+				env[1] = math.Min(env[1], pt[1])
+				env[2] = math.Max(env[2], pt[0])
+				env[3] = math.Max(env[3], pt[1])
+			}
+		}
+	}
+	return env
+}
+
+const (
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+)
+
+// WKB returns geometry in little endian WKB form
+func (g Geometry) WKB() []byte {
+	var buf bytes.Buffer
+	writePolygon := func(p Polygon) {
+		buf.WriteByte(1)
+		binary.Write(&buf, binary.LittleEndian, uint32(wkbPolygon))
+		binary.Write(&buf, binary.LittleEndian, uint32(len(p)))
+		for _, r := range p {
+			binary.Write(&buf, binary.LittleEndian, uint32(len(r)))
+			for _, pt := range r {
+				binary.Write(&buf, binary.LittleEndian, pt)
+			}
+		}
+	}
+	if !g.IsMulti && len(g.Polygons) == 1 {
+		writePolygon(g.Polygons[0])
+		return buf.Bytes()
+	}
+	buf.WriteByte(1)
+	binary.Write(&buf, binary.LittleEndian, uint32(wkbMultiPolygon))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(g.Polygons)))
+	for _, p := range g.Polygons {
+		writePolygon(p)
+	}
+	return buf.Bytes()
+}
+
+// ParseWKB parses POLYGON or MULTIPOLYGON in WKB form
+func ParseWKB(bs []byte) (Geometry, error) {
+	var g Geometry
+	r := bytes.NewReader(bs)
+	readHeader := func() (binary.ByteOrder, uint32, error) {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, err
+		}
+		var order binary.ByteOrder = binary.BigEndian
+		if b == 1 {
+			order = binary.LittleEndian
+		}
+		var t uint32
+		err = binary.Read(r, order, &t)
+		return order, t, err
+	}
+	readPolygon := func(order binary.ByteOrder) (Polygon, error) {
+		var n uint32
+		if err := binary.Read(r, order, &n); err != nil {
+			return nil, err
+		}
+		if int(n)*4 > r.Len() {
+			return nil, fmt.Errorf("polygon of %d rings exceeds WKB size", n)
+		}
+		p := make(Polygon, n)
+		for i := range p {
+			var m uint32
+			if err := binary.Read(r, order, &m); err != nil {
+				return nil, err
+			}
+			if int(m)*16 > r.Len() {
+				return nil, fmt.Errorf("ring of %d points exceeds WKB size", m)
+			}
+			p[i] = make(Ring, m)
+			if err := binary.Read(r, order, p[i]); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	}
+
+	order, t, err := readHeader()
+	if err != nil {
+		return g, fmt.Errorf("error on parse WKB: %s", err)
+	}
+	switch t {
+	case wkbPolygon:
+		p, err := readPolygon(order)
+		if err != nil {
+			return g, fmt.Errorf("error on parse WKB: %s", err)
+		}
+		g.Polygons = []Polygon{p}
+	case wkbMultiPolygon:
+		g.IsMulti = true
+		var n uint32
+		if err = binary.Read(r, order, &n); err != nil {
+			return g, fmt.Errorf("error on parse WKB: %s", err)
+		}
+		for i := uint32(0); i < n; i++ {
+			order, t, err := readHeader()
+			if err == nil && t != wkbPolygon {
+				err = fmt.Errorf("unexpected geometry type %d in multipolygon", t)
+			}
+			if err != nil {
+				return g, fmt.Errorf("error on parse WKB: %s", err)
+			}
+			p, err := readPolygon(order)
+			if err != nil {
+				return g, fmt.Errorf("error on parse WKB: %s", err)
+			}
+			g.Polygons = append(g.Polygons, p)
+		}
+	default:
+		return g, fmt.Errorf("unsupported WKB geometry type %d", t)
+	}
+	return g, nil
+}
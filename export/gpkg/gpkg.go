@@ -0,0 +1,270 @@
+// Package gpkg writes search results to GeoPackage files and reads them back. It is separated
+// from export package to keep SQLite driver out of programs not using GeoPackage.
+package gpkg
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/export"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultTable is a feature table name used if none is given
+const DefaultTable = "products"
+
+const (
+	applicationID = 0x47504B47 // "GPKG"
+	userVersion   = 10300      // GeoPackage 1.3
+	srsID         = 4326
+)
+
+var schema = []string{
+	fmt.Sprintf("PRAGMA application_id = %d", applicationID),
+	fmt.Sprintf("PRAGMA user_version = %d", userVersion),
+	`CREATE TABLE gpkg_spatial_ref_sys (
+		srs_name TEXT NOT NULL,
+		srs_id INTEGER NOT NULL PRIMARY KEY,
+		organization TEXT NOT NULL,
+		organization_coordsys_id INTEGER NOT NULL,
+		definition TEXT NOT NULL,
+		description TEXT)`,
+	`INSERT INTO gpkg_spatial_ref_sys VALUES
+		('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system'),
+		('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system'),
+		('WGS 84 geodetic', 4326, 'EPSG', 4326, 'GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]', 'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid')`,
+	`CREATE TABLE gpkg_contents (
+		table_name TEXT NOT NULL PRIMARY KEY,
+		data_type TEXT NOT NULL,
+		identifier TEXT UNIQUE,
+		description TEXT DEFAULT '',
+		last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')),
+		min_x DOUBLE, min_y DOUBLE, max_x DOUBLE, max_y DOUBLE,
+		srs_id INTEGER,
+		CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id))`,
+	`CREATE TABLE gpkg_geometry_columns (
+		table_name TEXT NOT NULL,
+		column_name TEXT NOT NULL,
+		geometry_type_name TEXT NOT NULL,
+		srs_id INTEGER NOT NULL,
+		z TINYINT NOT NULL,
+		m TINYINT NOT NULL,
+		CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name),
+		CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents(table_name),
+		CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id))`,
+}
+
+func sqlType(k export.Kind) string {
+	switch k {
+	case export.KindInteger:
+		return "INTEGER"
+	case export.KindReal:
+		return "REAL"
+	case export.KindTime:
+		return "DATETIME"
+	case export.KindBoolean:
+		return "BOOLEAN"
+	}
+	return "TEXT"
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// encodeGeometry returns GeoPackage geometry blob: header with envelope followed by WKB
+func encodeGeometry(g export.Geometry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("GP")
+	buf.WriteByte(0)    // version
+	buf.WriteByte(0x03) // little endian, [minx, maxx, miny, maxy] envelope
+	binary.Write(&buf, binary.LittleEndian, int32(srsID))
+	env := g.Envelope()
+	binary.Write(&buf, binary.LittleEndian, [4]float64{env[0], env[2], env[1], env[3]})
+	buf.Write(g.WKB())
+	return buf.Bytes()
+}
+
+func decodeGeometry(bs []byte) (export.Geometry, error) {
+	if len(bs) < 8 || string(bs[:2]) != "GP" {
+		return export.Geometry{}, fmt.Errorf("not a GeoPackage geometry")
+	}
+	envelopeSizes := map[byte]int{0: 0, 1: 32, 2: 48, 3: 48, 4: 64}
+	size, ok := envelopeSizes[(bs[3]>>1)&0x07]
+	if !ok || len(bs) < 8+size {
+		return export.Geometry{}, fmt.Errorf("invalid GeoPackage geometry header")
+	}
+	return export.ParseWKB(bs[8+size:])
+}
+
+// Write creates GeoPackage file with entries in feature table. Existing file is not overwritten.
+func Write(filePath string, table string, entries []sentinel.QueryEntryResponse) error {
+	if table == "" {
+		table = DefaultTable
+	}
+	if _, err := os.Stat(filePath); err == nil {
+		return fmt.Errorf("file %s already exists", filePath)
+	}
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		return fmt.Errorf("error on create GeoPackage: %s", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error on begin transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	columnDefs := []string{"fid INTEGER PRIMARY KEY AUTOINCREMENT", "geom GEOMETRY"}
+	columnNames := []string{"geom"}
+	placeholders := []string{"?"}
+	for _, c := range export.Columns {
+		columnDefs = append(columnDefs, quote(c.Name)+" "+sqlType(c.Kind))
+		columnNames = append(columnNames, quote(c.Name))
+		placeholders = append(placeholders, "?")
+	}
+	statements := make([]string, 0, len(schema)+1)
+	statements = append(statements, schema...)
+	statements = append(statements, fmt.Sprintf("CREATE TABLE %s (%s)", quote(table), strings.Join(columnDefs, ", ")))
+	for _, s := range statements {
+		if _, err = tx.Exec(s); err != nil {
+			return fmt.Errorf("error on create GeoPackage schema: %s", err)
+		}
+	}
+
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(table), strings.Join(columnNames, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return fmt.Errorf("error on prepare insert: %s", err)
+	}
+	defer insert.Close()
+
+	var extent export.Geometry
+	for i := range entries {
+		e := &entries[i]
+		values := []interface{}{nil}
+		if e.Footprint != "" {
+			g, err := export.ParseWKT(e.Footprint)
+			if err != nil {
+				return fmt.Errorf("error on convert footprint of %s: %s", e.Identifier, err)
+			}
+			values[0] = encodeGeometry(g)
+			extent.Polygons = append(extent.Polygons, g.Polygons...)
+		}
+		for _, c := range export.Columns {
+			v := c.Get(e)
+			switch {
+			case v == "":
+				values = append(values, nil)
+			case c.Kind == export.KindBoolean:
+				// GeoPackage booleans are stored as 0 and 1
+				values = append(values, v == "true")
+			default:
+				values = append(values, v)
+			}
+		}
+		if _, err = insert.Exec(values...); err != nil {
+			return fmt.Errorf("error on insert %s: %s", e.Identifier, err)
+		}
+	}
+
+	var env []interface{}
+	if len(extent.Polygons) > 0 {
+		e := extent.Envelope()
+		env = []interface{}{e[0], e[1], e[2], e[3]}
+	} else {
+		env = []interface{}{nil, nil, nil, nil}
+	}
+	if _, err = tx.Exec("INSERT INTO gpkg_contents (table_name, data_type, identifier, min_x, min_y, max_x, max_y, srs_id) VALUES (?, 'features', ?, ?, ?, ?, ?, ?)",
+		append(append([]interface{}{table, table}, env...), srsID)...); err != nil {
+		return fmt.Errorf("error on register table: %s", err)
+	}
+	if _, err = tx.Exec("INSERT INTO gpkg_geometry_columns VALUES (?, 'geom', 'GEOMETRY', ?, 0, 0)", table, srsID); err != nil {
+		return fmt.Errorf("error on register geometry column: %s", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error on commit: %s", err)
+	}
+	return nil
+}
+
+// Read reads entries from feature table written by Write. Columns are matched by name.
+func Read(filePath string, table string) ([]sentinel.QueryEntryResponse, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("error on open GeoPackage: %s", err)
+	}
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error on open GeoPackage: %s", err)
+	}
+	defer db.Close()
+
+	var geomColumn string
+	if err = db.QueryRow("SELECT column_name FROM gpkg_geometry_columns WHERE table_name = ?", table).Scan(&geomColumn); err != nil {
+		return nil, fmt.Errorf("error on find geometry column of %s: %s", table, err)
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s", quote(table)))
+	if err != nil {
+		return nil, fmt.Errorf("error on query %s: %s", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("error on get columns of %s: %s", table, err)
+	}
+
+	entries := make([]sentinel.QueryEntryResponse, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("error on read row: %s", err)
+		}
+
+		properties := make(map[string]string, len(columns))
+		footprint := ""
+		for i, name := range columns {
+			switch v := values[i].(type) {
+			case nil:
+			case []byte:
+				if name != geomColumn {
+					properties[name] = string(v)
+					continue
+				}
+				g, err := decodeGeometry(v)
+				if err != nil {
+					return nil, fmt.Errorf("error on read geometry: %s", err)
+				}
+				footprint = g.WKT()
+			case time.Time:
+				// driver parses DATETIME columns itself
+				properties[name] = v.UTC().Format(time.RFC3339Nano)
+			default:
+				properties[name] = fmt.Sprint(v)
+			}
+		}
+		e, err := export.FromProperties(properties, footprint)
+		if err != nil {
+			return nil, fmt.Errorf("error on read row: %s", err)
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error on read rows: %s", err)
+	}
+	return entries, nil
+}
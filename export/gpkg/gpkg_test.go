@@ -0,0 +1,55 @@
+package gpkg
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/export"
+)
+
+func TestWriteRead(t *testing.T) {
+	entries := []sentinel.QueryEntryResponse{{
+		ID:                   "uuid-1",
+		UUID:                 "uuid-1",
+		Identifier:           "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407",
+		Online:               true,
+		ContentLength:        1073741824,
+		Footprint:            "POLYGON((35.82 50.51,37.36 50.47,37.27 49.48,35.76 49.53,35.82 50.51))",
+		BeginPosition:        time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC),
+		RelativeOrbitNumber:  21,
+		CloudCoverPercentage: 12.345,
+	}, {
+		ID:   "uuid-2",
+		UUID: "uuid-2",
+	}}
+
+	filePath := filepath.Join(t.TempDir(), "results.gpkg")
+	if err := Write(filePath, "", entries); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if err := Write(filePath, "", entries); err == nil {
+		t.Errorf("existing file should not be overwritten")
+	}
+
+	res, err := Read(filePath, "")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("should be 2 entries, but got %d", len(res))
+	}
+	for i := range entries {
+		expected := export.Properties(&entries[i])
+		actual := export.Properties(&res[i])
+		for k := range expected {
+			if expected[k] != actual[k] {
+				t.Errorf("entry %d: %s should be %s, but is %s", i, k, expected[k], actual[k])
+			}
+		}
+		if res[i].Footprint != entries[i].Footprint {
+			t.Errorf("entry %d: footprint should be %s, but is %s", i, entries[i].Footprint, res[i].Footprint)
+		}
+	}
+}
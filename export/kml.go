@@ -0,0 +1,190 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlLinearRing struct {
+	Coordinates string `xml:"LinearRing>coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer kmlLinearRing   `xml:"outerBoundaryIs"`
+	Inner []kmlLinearRing `xml:"innerBoundaryIs"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin,omitempty"`
+	End   string `xml:"end,omitempty"`
+}
+
+type kmlPlacemark struct {
+	ID            string       `xml:"id,attr,omitempty"`
+	Name          string       `xml:"name"`
+	TimeSpan      *kmlTimeSpan `xml:"TimeSpan,omitempty"`
+	ExtendedData  []kmlData    `xml:"ExtendedData>Data"`
+	Polygon       *kmlPolygon  `xml:"Polygon,omitempty"`
+	MultiGeometry []kmlPolygon `xml:"MultiGeometry>Polygon,omitempty"`
+}
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	NS         string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+func (r Ring) kml() kmlLinearRing {
+	points := make([]string, len(r))
+	for i := range r {
+		points[i] = formatFloat(r[i][0]) + "," + formatFloat(r[i][1])
+	}
+	return kmlLinearRing{Coordinates: strings.Join(points, " ")}
+}
+
+func (p Polygon) kml() kmlPolygon {
+	res := kmlPolygon{}
+	for i := range p {
+		if i == 0 {
+			res.Outer = p[i].kml()
+			continue
+		}
+		res.Inner = append(res.Inner, p[i].kml())
+	}
+	return res
+}
+
+func (lr kmlLinearRing) ring() (Ring, error) {
+	points := strings.Fields(lr.Coordinates)
+	res := make(Ring, len(points))
+	for i := range points {
+		xyz := strings.Split(points[i], ",")
+		if len(xyz) < 2 {
+			return nil, fmt.Errorf("invalid point %q", points[i])
+		}
+		for j := 0; j < 2; j++ {
+			v, err := strconv.ParseFloat(xyz[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate %q", xyz[j])
+			}
+			res[i][j] = v
+		}
+	}
+	return res, nil
+}
+
+func (kp kmlPolygon) polygon() (Polygon, error) {
+	outer, err := kp.Outer.ring()
+	if err != nil {
+		return nil, err
+	}
+	res := Polygon{outer}
+	for _, lr := range kp.Inner {
+		inner, err := lr.ring()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inner)
+	}
+	return res, nil
+}
+
+// WriteKML writes entries as KML placemarks named by product identifier, with footprints as polygons,
+// sensing time as time span and other columns as extended data
+func WriteKML(w io.Writer, name string, entries []sentinel.QueryEntryResponse) error {
+	doc := kmlDocument{
+		NS:         "http://www.opengis.net/kml/2.2",
+		Name:       name,
+		Placemarks: make([]kmlPlacemark, len(entries)),
+	}
+	for i := range entries {
+		e := &entries[i]
+		pm := kmlPlacemark{
+			ID:   e.ID,
+			Name: e.Identifier,
+		}
+		if !e.BeginPosition.IsZero() {
+			props := Properties(e)
+			pm.TimeSpan = &kmlTimeSpan{Begin: props["beginposition"], End: props["endposition"]}
+		}
+		for _, c := range Columns {
+			pm.ExtendedData = append(pm.ExtendedData, kmlData{Name: c.Name, Value: c.Get(e)})
+		}
+		if e.Footprint != "" {
+			g, err := ParseWKT(e.Footprint)
+			if err != nil {
+				return fmt.Errorf("error on convert footprint of %s: %s", e.Identifier, err)
+			}
+			if g.IsMulti {
+				for _, p := range g.Polygons {
+					pm.MultiGeometry = append(pm.MultiGeometry, p.kml())
+				}
+			} else {
+				p := g.Polygons[0].kml()
+				pm.Polygon = &p
+			}
+		}
+		doc.Placemarks[i] = pm
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error on write KML: %s", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error on write KML: %s", err)
+	}
+	return nil
+}
+
+// ReadKML reads entries written by WriteKML
+func ReadKML(r io.Reader) ([]sentinel.QueryEntryResponse, error) {
+	var doc kmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error on read KML: %s", err)
+	}
+	entries := make([]sentinel.QueryEntryResponse, len(doc.Placemarks))
+	for i, pm := range doc.Placemarks {
+		var g Geometry
+		if pm.Polygon != nil {
+			p, err := pm.Polygon.polygon()
+			if err != nil {
+				return nil, fmt.Errorf("error on read geometry of placemark %d: %s", i, err)
+			}
+			g.Polygons = []Polygon{p}
+		}
+		for _, kp := range pm.MultiGeometry {
+			p, err := kp.polygon()
+			if err != nil {
+				return nil, fmt.Errorf("error on read geometry of placemark %d: %s", i, err)
+			}
+			g.IsMulti = true
+			g.Polygons = append(g.Polygons, p)
+		}
+
+		properties := make(map[string]string, len(pm.ExtendedData))
+		for _, d := range pm.ExtendedData {
+			properties[d.Name] = d.Value
+		}
+		var err error
+		if entries[i], err = FromProperties(properties, g.WKT()); err != nil {
+			return nil, fmt.Errorf("error on read data of placemark %d: %s", i, err)
+		}
+		if entries[i].Identifier == "" {
+			entries[i].Identifier = pm.Name
+		}
+	}
+	return entries, nil
+}
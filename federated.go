@@ -0,0 +1,122 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Hub is a named searcher taking part in federated search
+type Hub struct {
+	Name     string
+	Searcher ISentinelSearcher
+}
+
+type federatedSearcher struct {
+	hubs    []Hub
+	timeout time.Duration
+}
+
+// FederatedOption configures searcher created by NewFederatedSearcher
+type FederatedOption func(*federatedSearcher)
+
+// WithHubTimeout sets time to wait for each hub. Hubs not answered in time are skipped.
+// If timeout equals 0 then notimeout is used.
+func WithHubTimeout(timeout time.Duration) FederatedOption {
+	return func(fs *federatedSearcher) {
+		fs.timeout = timeout
+	}
+}
+
+// NewFederatedSearcher returns searcher querying all hubs concurrently. Results are merged in hubs order,
+// so on duplicates entry of the earlier hub wins. Query fails only if every hub fails.
+func NewFederatedSearcher(hubs []Hub, opts ...FederatedOption) (ISentinelSearcher, error) {
+	if len(hubs) == 0 {
+		return nil, fmt.Errorf("no hubs provided")
+	}
+	for i := range hubs {
+		if hubs[i].Searcher == nil {
+			return nil, fmt.Errorf("searcher of hub %q is nil", hubs[i].Name)
+		}
+	}
+	fs := federatedSearcher{
+		hubs: hubs,
+	}
+	for _, opt := range opts {
+		opt(&fs)
+	}
+	return fs, nil
+}
+
+type hubResult struct {
+	qr  QueryResponse
+	err error
+}
+
+func (fs federatedSearcher) Query(params SearchParameters) (QueryResponse, error) {
+	results := make([]chan hubResult, len(fs.hubs))
+	for i := range fs.hubs {
+		// buffered, so hub answering after timeout does not block forever
+		results[i] = make(chan hubResult, 1)
+		go func(h Hub, ch chan<- hubResult) {
+			qr, err := h.Searcher.Query(params)
+			ch <- hubResult{qr: qr, err: err}
+		}(fs.hubs[i], results[i])
+	}
+
+	// Done channel stays closed after timeout, so every hub still waiting is skipped
+	var deadline <-chan struct{}
+	if fs.timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), fs.timeout)
+		defer cancel()
+		deadline = ctx.Done()
+	}
+
+	var res QueryResponse
+	isAnswered := false
+	errList := make([]string, 0)
+	seenUUIDs := make(map[string]struct{})
+	seenIdentifiers := make(map[string]struct{})
+	for i, h := range fs.hubs {
+		var hr hubResult
+		select {
+		case hr = <-results[i]:
+		case <-deadline:
+			// hub may have answered in time too, select picks ready cases at random
+			select {
+			case hr = <-results[i]:
+			default:
+				hr.err = fmt.Errorf("timeout after %s", fs.timeout)
+			}
+		}
+		if hr.err != nil {
+			errList = append(errList, fmt.Sprintf("%s: %s", h.Name, hr.err))
+			continue
+		}
+		if !isAnswered {
+			res = hr.qr
+			res.Feed.Entries = make([]QueryEntryResponse, 0, len(hr.qr.Feed.Entries))
+			isAnswered = true
+		}
+		for _, e := range hr.qr.Feed.Entries {
+			if _, ok := seenUUIDs[e.UUID]; ok && e.UUID != "" {
+				continue
+			}
+			if _, ok := seenIdentifiers[e.Identifier]; ok && e.Identifier != "" {
+				continue
+			}
+			seenUUIDs[e.UUID] = struct{}{}
+			seenIdentifiers[e.Identifier] = struct{}{}
+			e.Hub = h.Name
+			res.Feed.Entries = append(res.Feed.Entries, e)
+		}
+	}
+	if !isAnswered {
+		return res, fmt.Errorf("all hubs failed: %s", strings.Join(errList, "; "))
+	}
+
+	res.Feed.TotalResults = len(res.Feed.Entries)
+	res.Feed.TotalResultsStr = fmt.Sprint(res.Feed.TotalResults)
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package sentinel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type funcSearcher func(params SearchParameters) (QueryResponse, error)
+
+func (f funcSearcher) Query(params SearchParameters) (QueryResponse, error) {
+	return f(params)
+}
+
+func staticSearcher(delay time.Duration, err error, ids ...string) funcSearcher {
+	return func(params SearchParameters) (QueryResponse, error) {
+		time.Sleep(delay)
+		var qr QueryResponse
+		for _, id := range ids {
+			qr.Feed.Entries = append(qr.Feed.Entries, QueryEntryResponse{UUID: id, Identifier: "P_" + id})
+		}
+		qr.Feed.TotalResults = len(ids)
+		return qr, err
+	}
+}
+
+func TestFederatedSearcher(t *testing.T) {
+	_, err := NewFederatedSearcher(nil)
+	if err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+
+	fs, err := NewFederatedSearcher([]Hub{
+		{Name: "broken", Searcher: staticSearcher(0, fmt.Errorf("503"))},
+		{Name: "primary", Searcher: staticSearcher(0, nil, "1", "2")},
+		{Name: "slow", Searcher: staticSearcher(time.Second, nil, "5")},
+		{Name: "mirror", Searcher: staticSearcher(0, nil, "2", "3")},
+	}, WithHubTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	res, err := fs.Query(SearchParameters{})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	expected := []struct{ uuid, hub string }{{"1", "primary"}, {"2", "primary"}, {"3", "mirror"}}
+	if len(res.Feed.Entries) != len(expected) || res.Feed.TotalResults != len(expected) {
+		t.Fatalf("should be %d entries, but got %d", len(expected), len(res.Feed.Entries))
+	}
+	for i, e := range expected {
+		if res.Feed.Entries[i].UUID != e.uuid || res.Feed.Entries[i].Hub != e.hub {
+			t.Errorf("entry %d should be %s from %s, but is %s from %s", i, e.uuid, e.hub, res.Feed.Entries[i].UUID, res.Feed.Entries[i].Hub)
+		}
+	}
+
+	fs, _ = NewFederatedSearcher([]Hub{{Name: "broken", Searcher: staticSearcher(0, fmt.Errorf("503"))}})
+	if _, err = fs.Query(SearchParameters{}); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+}
+
+func TestFederatedSearcherHungHubs(t *testing.T) {
+	hung := make(chan struct{})
+	defer close(hung)
+	hungSearcher := funcSearcher(func(params SearchParameters) (QueryResponse, error) {
+		<-hung
+		return QueryResponse{}, nil
+	})
+	fs, _ := NewFederatedSearcher([]Hub{
+		{Name: "hung1", Searcher: hungSearcher},
+		{Name: "hung2", Searcher: hungSearcher},
+		{Name: "primary", Searcher: staticSearcher(0, nil, "1")},
+	}, WithHubTimeout(50*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() {
+		res, err := fs.Query(SearchParameters{})
+		if err == nil && len(res.Feed.Entries) != 1 {
+			err = fmt.Errorf("should be 1 entry, but got %d", len(res.Feed.Entries))
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("error should be nil, but is %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("query should not wait for hung hubs after timeout")
+	}
+}
@@ -0,0 +1,197 @@
+// Package layout builds file paths of products from templates like
+// {platform}/{tile}/{year}/{month}/{identifier}.zip. Values are taken from search result
+// entries, missing ones are parsed from product names.
+package layout
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/safe"
+	"github.com/therox/go-sentinel/storage"
+)
+
+// Fields available in templates
+var fields = map[string]func(e *sentinel.QueryEntryResponse) string{
+	"platform":       func(e *sentinel.QueryEntryResponse) string { return e.PlatformName },
+	"mission":        func(e *sentinel.QueryEntryResponse) string { return mission(e.Identifier) },
+	"product_type":   func(e *sentinel.QueryEntryResponse) string { return e.ProductType },
+	"tile":           func(e *sentinel.QueryEntryResponse) string { return e.TileId },
+	"relative_orbit": func(e *sentinel.QueryEntryResponse) string { return formatOrbit(e.RelativeOrbitNumber) },
+	"year":           func(e *sentinel.QueryEntryResponse) string { return formatDate(e.BeginPosition, "2006") },
+	"month":          func(e *sentinel.QueryEntryResponse) string { return formatDate(e.BeginPosition, "01") },
+	"day":            func(e *sentinel.QueryEntryResponse) string { return formatDate(e.BeginPosition, "02") },
+	"date":           func(e *sentinel.QueryEntryResponse) string { return formatDate(e.BeginPosition, "20060102") },
+	"identifier":     func(e *sentinel.QueryEntryResponse) string { return e.Identifier },
+	"uuid":           func(e *sentinel.QueryEntryResponse) string { return e.UUID },
+}
+
+var rePlaceholder = regexp.MustCompile(`\{([a-z_]+)(\|([^{}]*))?\}`)
+
+// Template is a parsed path template. Placeholders are {platform} (Sentinel-2), {mission} (S2A),
+// {product_type}, {tile}, {relative_orbit} (021), {year}, {month}, {day}, {date} (20220101),
+// {identifier} and {uuid}. {field|default} gives value used when field is unknown, otherwise
+// unknown fields are errors. Slashes separate directories on any OS.
+type Template struct {
+	raw string
+}
+
+// Parse validates template
+func Parse(template string) (Template, error) {
+	if template == "" {
+		return Template{}, fmt.Errorf("template is empty")
+	}
+	if path.IsAbs(template) || strings.HasPrefix(template, `\`) {
+		return Template{}, fmt.Errorf("template %s is absolute", template)
+	}
+	for _, m := range rePlaceholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := fields[m[1]]; !ok {
+			return Template{}, fmt.Errorf("unknown field {%s} in template %s", m[1], template)
+		}
+	}
+	if rest := rePlaceholder.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return Template{}, fmt.Errorf("unbalanced braces in template %s", template)
+	}
+	return Template{raw: template}, nil
+}
+
+// MustParse is like Parse but panics on error
+func MustParse(template string) Template {
+	t, err := Parse(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t Template) String() string {
+	return t.raw
+}
+
+// Path returns relative path of entry product in OS format. Fields missing in entry are
+// parsed from its Identifier, Title or FileName
+func (t Template) Path(e sentinel.QueryEntryResponse) (string, error) {
+	e = Complete(e)
+	var err error
+	res := rePlaceholder.ReplaceAllStringFunc(t.raw, func(s string) string {
+		m := rePlaceholder.FindStringSubmatch(s)
+		v := fields[m[1]](&e)
+		if v == "" {
+			v = m[3]
+		}
+		if v == "" && m[2] == "" && err == nil {
+			err = fmt.Errorf("no value of {%s} for product %s", m[1], e.Identifier)
+		}
+		// values must not add directories or escape root
+		return storage.SafeName(v)
+	})
+	if err != nil {
+		return "", err
+	}
+	res = path.Clean(res)
+	if res == "." || res == ".." || strings.HasPrefix(res, "../") {
+		return "", fmt.Errorf("template %s gives invalid path %s", t.raw, res)
+	}
+	return filepath.FromSlash(res), nil
+}
+
+// Complete returns entry with identifier, platform, product type, tile, relative orbit and
+// sensing start filled in from product name where they are empty
+func Complete(e sentinel.QueryEntryResponse) sentinel.QueryEntryResponse {
+	if e.Identifier == "" {
+		e.Identifier = e.Title
+	}
+	if e.Identifier == "" {
+		e.Identifier = strings.TrimSuffix(e.FileName, filepath.Ext(e.FileName))
+	}
+	n := FromName(e.Identifier)
+	if e.PlatformName == "" {
+		e.PlatformName = n.PlatformName
+	}
+	if e.ProductType == "" {
+		e.ProductType = n.ProductType
+	}
+	if e.TileId == "" {
+		e.TileId = n.TileId
+	}
+	if e.RelativeOrbitNumber == 0 {
+		e.RelativeOrbitNumber = n.RelativeOrbitNumber
+	}
+	if e.BeginPosition.IsZero() {
+		e.BeginPosition = n.BeginPosition
+	}
+	return e
+}
+
+var (
+	reSensingTime = regexp.MustCompile(`_(\d{8}T\d{6})`)
+	reS2Orbit     = regexp.MustCompile(`_R(\d{3})_`)
+)
+
+// FromName returns entry with fields parsed from product name, e.g.
+// S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407 or
+// S1A_IW_GRDH_1SDV_20220101T034512_20220101T034537_041260_04E7A2_5F2B.
+// Extensions .zip, .SAFE and .SEN3 are ignored
+func FromName(name string) sentinel.QueryEntryResponse {
+	name = path.Base(filepath.ToSlash(name))
+	for _, ext := range []string{".zip", ".SAFE", ".SEN3"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	e := sentinel.QueryEntryResponse{Identifier: name, TileId: safe.TileFromName(name)}
+	if len(name) < 3 || name[0] != 'S' || name[1] < '1' || name[1] > '6' {
+		return e
+	}
+	e.PlatformName = "Sentinel-" + name[1:2]
+	if m := reSensingTime.FindStringSubmatch(name); m != nil {
+		e.BeginPosition, _ = time.Parse("20060102T150405", m[1])
+	}
+	parts := strings.Split(name, "_")
+	switch name[1] {
+	case '1':
+		// S1A_IW_GRDH_1SDV: type is GRD, SLC or OCN without resolution letter
+		if len(parts) > 2 && len(parts[2]) >= 3 {
+			e.ProductType = parts[2][:3]
+		}
+	case '2':
+		// S2A_MSIL2A: hub type is S2MSI2A
+		if len(parts) > 1 && strings.HasPrefix(parts[1], "MSIL") {
+			e.ProductType = "S2MSI" + strings.TrimPrefix(parts[1], "MSIL")
+		}
+		if m := reS2Orbit.FindStringSubmatch(name); m != nil {
+			e.RelativeOrbitNumber, _ = strconv.Atoi(m[1])
+		}
+	case '3':
+		// S3A_OL_2_LFR____: type is OL_2_LFR___ as in the hub
+		if len(name) >= 15 {
+			e.ProductType = name[4:15]
+		}
+	}
+	return e
+}
+
+func mission(identifier string) string {
+	if len(identifier) >= 3 && identifier[0] == 'S' {
+		return identifier[:3]
+	}
+	return ""
+}
+
+func formatOrbit(orbit int) string {
+	if orbit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%03d", orbit)
+}
+
+func formatDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(layout)
+}
@@ -0,0 +1,50 @@
+package layout
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+func TestPath(t *testing.T) {
+	s2 := "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407"
+	s1 := "S1A_IW_GRDH_1SDV_20220305T034512_20220305T034537_042210_0508A2_5F2B"
+	cases := []struct {
+		template string
+		entry    sentinel.QueryEntryResponse
+		path     string
+	}{
+		{"{platform}/{tile}/{year}/{month}/{identifier}.zip", sentinel.QueryEntryResponse{Identifier: s2}, "Sentinel-2/36UYA/2022/01/" + s2 + ".zip"},
+		{"{mission}/{product_type}/R{relative_orbit}/{date}/{identifier}.SAFE", sentinel.QueryEntryResponse{FileName: s2 + ".SAFE"}, "S2A/S2MSI2A/R021/20220101/" + s2 + ".SAFE"},
+		{"{platform}/{product_type}/{tile|no-tile}/{year}/{day}/{uuid}.zip", sentinel.QueryEntryResponse{Title: s1, UUID: "u1"}, "Sentinel-1/GRD/no-tile/2022/05/u1.zip"},
+		// entry fields take precedence over name
+		{"{tile}/{year}/{identifier}", sentinel.QueryEntryResponse{Identifier: s2, TileId: "35UQR", BeginPosition: time.Date(2021, 12, 31, 23, 0, 0, 0, time.UTC)}, "35UQR/2021/" + s2},
+		// values can not add directories
+		{"{product_type}/{identifier}", sentinel.QueryEntryResponse{Identifier: "../x", ProductType: "a/b"}, "a_b/__x"},
+	}
+	for _, c := range cases {
+		tmpl, err := Parse(c.template)
+		if err != nil {
+			t.Fatalf("%s: error should be nil, but is %s", c.template, err)
+		}
+		p, err := tmpl.Path(c.entry)
+		if err != nil {
+			t.Errorf("%s: error should be nil, but is %s", c.template, err)
+			continue
+		}
+		if p != filepath.FromSlash(c.path) {
+			t.Errorf("%s: path should be %s, but is %s", c.template, c.path, p)
+		}
+	}
+
+	if _, err := MustParse("{tile}/{identifier}").Path(sentinel.QueryEntryResponse{Identifier: s1}); err == nil {
+		t.Errorf("err is nil but should not be for missing tile")
+	}
+	for _, tmpl := range []string{"", "/data/{identifier}", "{unknown}/{identifier}", "{tile/{identifier}"} {
+		if _, err := Parse(tmpl); err == nil {
+			t.Errorf("%q: err is nil but should not be", tmpl)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/therox/go-sentinel/storage"
+)
+
+// MultiEngine is a download engine trying several engines (hub mirrors, caches) in order.
+// Product is downloaded from the first engine having it online. If none of them has,
+// retrieval from long-term archive is triggered on the primary engine only.
+type MultiEngine struct {
+	engines []dlEngine
+}
+
+// NewMultiEngine returns MultiEngine with given primary engine and mirrors in order of preference
+func NewMultiEngine(primary dlEngine, mirrors ...dlEngine) (MultiEngine, error) {
+	if primary == nil {
+		return MultiEngine{}, fmt.Errorf("primary engine is nil")
+	}
+	engines := []dlEngine{primary}
+	for i := range mirrors {
+		if mirrors[i] == nil {
+			return MultiEngine{}, fmt.Errorf("mirror engine %d is nil", i)
+		}
+		engines = append(engines, mirrors[i])
+	}
+	return MultiEngine{engines: engines}, nil
+}
+
+func (me MultiEngine) Download(productID string, dst string) (string, error) {
+	return me.download(productID, func(e dlEngine) (string, error) {
+		return e.Download(productID, dst)
+	})
+}
+
+// DownloadTo streams product to sink from the first engine having it online, see Download
+func (me MultiEngine) DownloadTo(productID string, sink storage.Sink) (string, error) {
+	return me.download(productID, func(e dlEngine) (string, error) {
+		sd, ok := e.(sinkDownloader)
+		if !ok {
+			return "", fmt.Errorf("engine does not support storage sinks")
+		}
+		return sd.DownloadTo(productID, sink)
+	})
+}
+
+// DownloadEntry downloads entry product from the first engine having it online, see Download.
+// Engines without entry support download by ID
+func (me MultiEngine) DownloadEntry(entry QueryEntryResponse, dst string) (string, error) {
+	productID := entry.GetID()
+	if productID == "" {
+		productID = entry.UUID
+	}
+	return me.download(productID, func(e dlEngine) (string, error) {
+		if ed, ok := e.(entryDownloader); ok {
+			return ed.DownloadEntry(entry, dst)
+		}
+		return e.Download(productID, dst)
+	})
+}
+
+func (me MultiEngine) download(productID string, fn func(e dlEngine) (string, error)) (string, error) {
+	errList := make([]error, 0)
+	isPrimaryOffline := false
+	for i, e := range me.engines {
+		isOnline, err := e.IsOnline(productID)
+		if err != nil {
+			errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+			continue
+		}
+		if !isOnline {
+			if i == 0 {
+				isPrimaryOffline = true
+			}
+			continue
+		}
+		filePath, err := fn(e)
+		if err == nil {
+			return filePath, nil
+		}
+		errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+	}
+
+	if isPrimaryOffline {
+		// Primary responds with ErrFileTriggered when retrieval is started
+		return fn(me.engines[0])
+	}
+	if len(errList) == 0 {
+		return "", fmt.Errorf("product %s is not online on any engine", productID)
+	}
+	return "", fmt.Errorf("error on download %s: %w", productID, errors.Join(errList...))
+}
+
+// Verify checks file against checksums of engines in order, it passes if any of them matches
+func (me MultiEngine) Verify(productID string, filePath string) error {
+	errList := make([]error, 0)
+	for i, e := range me.engines {
+		v, ok := e.(productVerifier)
+		if !ok {
+			continue
+		}
+		err := v.Verify(productID, filePath)
+		if err == nil {
+			return nil
+		}
+		errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+	}
+	if len(errList) == 0 {
+		return fmt.Errorf("no engine verifies products")
+	}
+	return fmt.Errorf("error on verify %s: %w", productID, errors.Join(errList...))
+}
+
+// IsOnline reports whether any of engines has product online
+func (me MultiEngine) IsOnline(productID string) (bool, error) {
+	errList := make([]error, 0)
+	for i, e := range me.engines {
+		isOnline, err := e.IsOnline(productID)
+		if err != nil {
+			errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+			continue
+		}
+		if isOnline {
+			return true, nil
+		}
+	}
+	if len(errList) == len(me.engines) {
+		return false, fmt.Errorf("error on get online status of %s: %w", productID, errors.Join(errList...))
+	}
+	return false, nil
+}
+
+// GetProduct returns product metadata from the first engine able to provide it
+func (me MultiEngine) GetProduct(productID string) (QueryEntryResponse, error) {
+	errList := make([]error, 0)
+	for i, e := range me.engines {
+		pg, ok := e.(productGetter)
+		if !ok {
+			continue
+		}
+		entry, err := pg.GetProduct(productID)
+		if err == nil {
+			return entry, nil
+		}
+		errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+	}
+	if len(errList) == 0 {
+		return QueryEntryResponse{}, fmt.Errorf("no engine provides product metadata")
+	}
+	return QueryEntryResponse{}, fmt.Errorf("error on get product %s: %w", productID, errors.Join(errList...))
+}
+
+// DownloadQuicklook downloads product preview from the first engine able to provide it
+func (me MultiEngine) DownloadQuicklook(productID string, dst string) (string, error) {
+	errList := make([]error, 0)
+	for i, e := range me.engines {
+		qd, ok := e.(quicklookDownloader)
+		if !ok {
+			continue
+		}
+		filePath, err := qd.DownloadQuicklook(productID, dst)
+		if err == nil {
+			return filePath, nil
+		}
+		errList = append(errList, fmt.Errorf("engine %d: %w", i, err))
+	}
+	if len(errList) == 0 {
+		return "", fmt.Errorf("no engine provides quicklooks")
+	}
+	return "", fmt.Errorf("error on download quicklook %s: %w", productID, errors.Join(errList...))
+}
@@ -0,0 +1,99 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/storage"
+)
+
+type recordingDlEngine struct {
+	name        string
+	isOnline    bool
+	downloadErr error
+	downloads   *[]string
+}
+
+func (m recordingDlEngine) Download(productID string, dst string) (string, error) {
+	*m.downloads = append(*m.downloads, m.name)
+	if m.downloadErr != nil {
+		return "", m.downloadErr
+	}
+	return dst + "/" + m.name, nil
+}
+
+func (m recordingDlEngine) IsOnline(productID string) (bool, error) {
+	return m.isOnline, nil
+}
+
+func TestMultiEngineDownload(t *testing.T) {
+	var downloads []string
+	primary := recordingDlEngine{name: "primary", downloads: &downloads}
+	broken := recordingDlEngine{name: "broken", isOnline: true, downloadErr: fmt.Errorf("500"), downloads: &downloads}
+	mirror := recordingDlEngine{name: "mirror", isOnline: true, downloads: &downloads}
+
+	me, err := NewMultiEngine(primary, broken, mirror)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	filePath, err := me.Download("id", "/tmp")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if filePath != "/tmp/mirror" {
+		t.Errorf("should be downloaded from mirror, but got %s", filePath)
+	}
+	if fmt.Sprint(downloads) != "[broken mirror]" {
+		t.Errorf("unexpected download attempts %v", downloads)
+	}
+
+	// nothing online: only primary is asked to trigger retrieval
+	downloads = nil
+	triggered := fmt.Errorf("triggered")
+	primary.downloadErr = triggered
+	me, _ = NewMultiEngine(primary, recordingDlEngine{name: "offline", downloads: &downloads})
+	if _, err = me.Download("id", "/tmp"); err != triggered {
+		t.Errorf("error should be %s, but is %v", triggered, err)
+	}
+	if fmt.Sprint(downloads) != "[primary]" {
+		t.Errorf("unexpected download attempts %v", downloads)
+	}
+}
+
+type entryRecordingDlEngine struct {
+	recordingDlEngine
+}
+
+func (m entryRecordingDlEngine) DownloadEntry(entry QueryEntryResponse, dst string) (string, error) {
+	*m.downloads = append(*m.downloads, m.name+":"+entry.Identifier)
+	return dst + "/" + entry.Identifier, nil
+}
+
+func TestMultiEngineErrors(t *testing.T) {
+	var downloads []string
+	quotaErr := quota.ErrQuotaExceeded{Kind: quota.KindDownloads}
+	broken := recordingDlEngine{name: "broken", isOnline: true, downloadErr: quotaErr, downloads: &downloads}
+	me, _ := NewMultiEngine(broken, broken)
+	_, err := me.Download("id", "/tmp")
+	var qe quota.ErrQuotaExceeded
+	if !errors.As(err, &qe) {
+		t.Errorf("error should wrap ErrQuotaExceeded, but is %v", err)
+	}
+	if _, err = me.DownloadTo("id", storage.Writer(io.Discard)); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+
+	downloads = nil
+	mirror := entryRecordingDlEngine{recordingDlEngine{name: "mirror", isOnline: true, downloads: &downloads}}
+	me, _ = NewMultiEngine(broken, mirror)
+	filePath, err := me.DownloadEntry(QueryEntryResponse{UUID: "id", Identifier: "P"}, "/tmp")
+	if err != nil || filePath != "/tmp/P" {
+		t.Errorf("entry should be downloaded from mirror, got %s, error %v", filePath, err)
+	}
+	if fmt.Sprint(downloads) != "[broken mirror:P]" {
+		t.Errorf("unexpected download attempts %v", downloads)
+	}
+}
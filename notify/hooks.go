@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+	"github.com/therox/go-sentinel/scheduler"
+)
+
+// WatchHandler returns scheduler handler publishing EventNewProducts for new entries
+func WatchHandler(d *Dispatcher) scheduler.Handler {
+	return func(ctx context.Context, w scheduler.Watch, entries []sentinel.QueryEntryResponse) error {
+		e := NewEvent(EventNewProducts)
+		e.Watch = w.Name
+		e.Entries = entries
+		return d.Publish(ctx, e)
+	}
+}
+
+// JobHook returns queue worker finish hook publishing EventDownloadDone or EventDownloadFailed.
+// Workers are not blocked by broken sinks, events are dropped if sink buffer is full
+func JobHook(d *Dispatcher) func(queue.Job) {
+	return func(job queue.Job) {
+		t := EventDownloadDone
+		if job.Status != queue.StatusDone {
+			t = EventDownloadFailed
+		}
+		e := NewEvent(t)
+		e.Job = &job
+		e.Watch = job.Source
+		d.TryPublish(e)
+	}
+}
+
+// WatchWebhooks returns sinks posting events of watches to their Webhook URLs, signed with secret if set.
+// Watches without webhook get no sink
+func WatchWebhooks(watches []scheduler.Watch, secret string) []Sink {
+	sinks := make([]Sink, 0)
+	for _, w := range watches {
+		if w.Webhook != "" {
+			sinks = append(sinks, WatchFilter{Watch: w.Name, Sink: Webhook{URL: w.Webhook, Secret: secret}})
+		}
+	}
+	return sinks
+}
@@ -0,0 +1,211 @@
+// Package notify delivers events about new products and finished downloads to sinks:
+// HTTP webhooks, local commands and spool directories. Dispatcher retries failed deliveries
+// per sink, so one broken sink does not hold back the others.
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+)
+
+// EventType is a type of event
+type EventType string
+
+const (
+	EventNewProducts    EventType = "products.new"    // saved search found products not seen before
+	EventDownloadDone   EventType = "download.done"   // download job is done
+	EventDownloadFailed EventType = "download.failed" // download job failed permanently
+)
+
+// Event is delivered to sinks as JSON
+type Event struct {
+	ID      string                        `json:"id"`
+	Type    EventType                     `json:"type"`
+	Time    time.Time                     `json:"time"`
+	Watch   string                        `json:"watch,omitempty"`
+	Entries []sentinel.QueryEntryResponse `json:"entries,omitempty"`
+	Job     *queue.Job                    `json:"job,omitempty"`
+}
+
+// NewEvent returns event of given type with new ID and current time
+func NewEvent(t EventType) Event {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return Event{ID: hex.EncodeToString(b), Type: t, Time: time.Now().UTC()}
+}
+
+// Sink delivers events. Send should be idempotent per event ID since it is retried
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+// Dispatcher delivers published events to all its sinks asynchronously, retrying failures
+type Dispatcher struct {
+	sinks       []Sink
+	channels    []chan Event
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	logger      *slog.Logger
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures Dispatcher
+type Option func(*Dispatcher)
+
+// WithRetries sets number of delivery attempts per sink and backoff before the second one,
+// doubled for each next attempt up to maxBackoff. Defaults are 5, 1s and 5m
+func WithRetries(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		if maxAttempts > 0 {
+			d.maxAttempts = maxAttempts
+		}
+		d.backoff = backoff
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// WithLogger sets logger of failed deliveries
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// WithBuffer sets number of events buffered per sink before Publish blocks, 100 by default
+func WithBuffer(n int) Option {
+	return func(d *Dispatcher) {
+		for i := range d.channels {
+			d.channels[i] = make(chan Event, n)
+		}
+	}
+}
+
+// NewDispatcher returns dispatcher delivering to sinks. Start it with Run
+func NewDispatcher(sinks []Sink, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		sinks:       sinks,
+		channels:    make([]chan Event, len(sinks)),
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxBackoff:  5 * time.Minute,
+		logger:      slog.New(slog.DiscardHandler),
+		sleep:       sleep,
+	}
+	for i := range d.channels {
+		d.channels[i] = make(chan Event, 100)
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Publish queues event for delivery to every sink. It blocks while a sink buffer is full
+func (d *Dispatcher) Publish(ctx context.Context, e Event) error {
+	for _, ch := range d.channels {
+		select {
+		case ch <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// TryPublish queues event for delivery to every sink without blocking. Sinks with full buffer,
+// e.g. broken for long, miss the event, it is logged as dropped
+func (d *Dispatcher) TryPublish(e Event) {
+	for i, ch := range d.channels {
+		select {
+		case ch <- e:
+		default:
+			d.logEvent(d.sinks[i], e, "event is dropped, sink buffer is full", nil)
+		}
+	}
+}
+
+func (d *Dispatcher) logEvent(sink Sink, e Event, msg string, err error) {
+	attrs := []any{
+		slog.String("sink", sink.Name()),
+		slog.String("event", e.ID),
+		slog.String("type", string(e.Type)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	d.logger.Error(msg, attrs...)
+}
+
+// Run delivers events until ctx is done. Events still buffered then are not delivered,
+// they are logged as dropped
+func (d *Dispatcher) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := range d.sinks {
+		wg.Add(1)
+		go func(sink Sink, ch chan Event) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					for len(ch) > 0 {
+						d.logEvent(sink, <-ch, "event is dropped, dispatcher is stopped", nil)
+					}
+					return
+				case e := <-ch:
+					if err := d.Deliver(ctx, sink, e); err != nil {
+						d.logEvent(sink, e, "event is not delivered", err)
+					}
+				}
+			}
+		}(d.sinks[i], d.channels[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// Deliver sends event to sink synchronously, retrying with backoff
+func (d *Dispatcher) Deliver(ctx context.Context, sink Sink, e Event) error {
+	var err error
+	for attempt := 0; attempt < d.maxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := d.backoff << (attempt - 1)
+			if d.maxBackoff > 0 && (delay > d.maxBackoff || delay <= 0) {
+				delay = d.maxBackoff
+			}
+			d.logger.Warn("event delivery retried",
+				slog.String("sink", sink.Name()),
+				slog.String("event", e.ID),
+				slog.String("error", err.Error()),
+				slog.Duration("delay", delay),
+			)
+			if serr := d.sleep(ctx, delay); serr != nil {
+				return fmt.Errorf("%s: %s", sink.Name(), err)
+			}
+		}
+		if err = sink.Send(ctx, e); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %d attempts failed, last error: %s", sink.Name(), d.maxAttempts, err)
+}
@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+	"github.com/therox/go-sentinel/scheduler"
+)
+
+func TestWebhook(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !VerifySignature("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(HeaderEvent) != string(EventNewProducts) {
+			t.Errorf("event header should be %s, but is %s", EventNewProducts, r.Header.Get(HeaderEvent))
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	e := NewEvent(EventNewProducts)
+	e.Watch = "tiles"
+	e.Entries = []sentinel.QueryEntryResponse{{UUID: "uuid-1"}}
+	if err := (Webhook{URL: srv.URL, Secret: "secret"}).Send(context.Background(), e); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if got.ID != e.ID || got.Watch != "tiles" || len(got.Entries) != 1 {
+		t.Errorf("unexpected event received: %+v", got)
+	}
+	if err := (Webhook{URL: srv.URL, Secret: "wrong"}).Send(context.Background(), e); err == nil {
+		t.Errorf("err is nil but should not be for wrong secret")
+	}
+}
+
+func TestWatchWebhooks(t *testing.T) {
+	watches := []scheduler.Watch{{Name: "tiles", Webhook: "http://example.org/hook"}, {Name: "silent"}}
+	sinks := WatchWebhooks(watches, "secret")
+	if len(sinks) != 1 {
+		t.Fatalf("should be 1 sink, but got %d", len(sinks))
+	}
+
+	ok := &flakySink{}
+	f := WatchFilter{Watch: "tiles", Sink: ok}
+	for _, watch := range []string{"tiles", "other", ""} {
+		e := NewEvent(EventNewProducts)
+		e.Watch = watch
+		if err := f.Send(context.Background(), e); err != nil {
+			t.Fatalf("error should be nil, but is %s", err)
+		}
+	}
+	if events := ok.received(); len(events) != 1 || events[0].Watch != "tiles" {
+		t.Errorf("only events of the watch should be passed, got %+v", events)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	e := NewEvent(EventDownloadDone)
+	e.Job = &queue.Job{ID: "job-1", ProductID: "uuid-1", Status: queue.StatusDone}
+	s := Spool{Dir: dir}
+	// repeated delivery overwrites the same file
+	for i := 0; i < 2; i++ {
+		if err := s.Send(context.Background(), e); err != nil {
+			t.Fatalf("error should be nil, but is %s", err)
+		}
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != "download_done-"+e.ID+".json" {
+		t.Fatalf("spool should contain single event file, but contains %v", files)
+	}
+	var got Event
+	bs, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err := json.Unmarshal(bs, &got); err != nil || got.Job.ProductID != "uuid-1" {
+		t.Errorf("unexpected event file: %s", bs)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	e := NewEvent(EventDownloadDone)
+	e.Job = &queue.Job{ProductID: "uuid-1", FilePath: "/data/p.zip"}
+	c := Command{Path: "sh", Args: []string{"-c", `echo "$SENTINEL_EVENT_TYPE $SENTINEL_FILE_PATH" > "$OUT"; cat >> "$OUT"`}, Env: []string{"OUT=" + out}}
+	if err := c.Send(context.Background(), e); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	bs, _ := os.ReadFile(out)
+	if want := "download.done /data/p.zip\n{"; len(bs) < len(want) || string(bs[:len(want)]) != want {
+		t.Errorf("unexpected command output: %s", bs)
+	}
+	if err := (Command{Path: "sh", Args: []string{"-c", "echo failed >&2; exit 3"}}).Send(context.Background(), e); err == nil {
+		t.Errorf("err is nil but should not be for non-zero exit")
+	}
+}
+
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	events   []Event
+}
+
+func (s *flakySink) Name() string { return "flaky" }
+
+func (s *flakySink) Send(ctx context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *flakySink) received() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func TestDispatcher(t *testing.T) {
+	flaky := &flakySink{failures: 2}
+	broken := &flakySink{failures: 100}
+	ok := &flakySink{}
+	d := NewDispatcher([]Sink{flaky, broken, ok}, WithRetries(3, time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	handler := WatchHandler(d)
+	if err := handler(ctx, scheduler.Watch{Name: "tiles"}, []sentinel.QueryEntryResponse{{UUID: "uuid-1"}}); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	JobHook(d)(queue.Job{ProductID: "uuid-1", Status: queue.StatusFailed, Source: "tiles"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for (len(flaky.received()) < 2 || len(ok.received()) < 2) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	for _, s := range []*flakySink{flaky, ok} {
+		events := s.received()
+		if len(events) != 2 {
+			t.Fatalf("sink should receive 2 events, but got %d", len(events))
+		}
+		if events[0].Type != EventNewProducts || events[0].Watch != "tiles" {
+			t.Errorf("unexpected first event: %+v", events[0])
+		}
+		if events[1].Type != EventDownloadFailed || events[1].Job == nil {
+			t.Errorf("unexpected second event: %+v", events[1])
+		}
+	}
+	if len(broken.received()) != 0 {
+		t.Errorf("broken sink should not receive events")
+	}
+}
+
+func TestJobHookFullBuffer(t *testing.T) {
+	d := NewDispatcher([]Sink{&flakySink{}}, WithBuffer(1))
+	hook := JobHook(d)
+	done := make(chan struct{})
+	go func() {
+		// dispatcher is not running, the second event does not fit the buffer
+		hook(queue.Job{ProductID: "uuid-1", Status: queue.StatusDone})
+		hook(queue.Job{ProductID: "uuid-2", Status: queue.StatusDone})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("job hook should not block on full buffer")
+	}
+	if len(d.channels[0]) != 1 {
+		t.Errorf("should be 1 buffered event, but got %d", len(d.channels[0]))
+	}
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set by Webhook
+const (
+	HeaderEvent     = "X-Sentinel-Event"
+	HeaderDelivery  = "X-Sentinel-Delivery"
+	HeaderTimestamp = "X-Sentinel-Timestamp"
+	HeaderSignature = "X-Sentinel-Signature"
+)
+
+// Webhook posts events as JSON. If Secret is set, request is signed with HMAC-SHA256 of
+// "<timestamp>.<body>" sent in X-Sentinel-Signature as "sha256=<hex>", see VerifySignature
+type Webhook struct {
+	URL    string
+	Secret string
+	Header http.Header  // additional request headers
+	Client *http.Client // 30s timeout client if nil
+}
+
+func (w Webhook) Name() string {
+	return "webhook " + w.URL
+}
+
+func (w Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error on marshal event: %s", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error on create request: %s", err)
+	}
+	for k, v := range w.Header {
+		req.Header[k] = v
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(e.Type))
+	req.Header.Set(HeaderDelivery, e.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if w.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, body))
+	}
+
+	c := w.Client
+	if c == nil {
+		c = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("error on POST event: %s", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns webhook signature of body sent at timestamp
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks webhook request signature. Receivers should also reject old timestamps
+func VerifySignature(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Command runs a local command per event. Event JSON is written to its stdin, event type, ID,
+// product ID and downloaded file path are passed in SENTINEL_EVENT_TYPE, SENTINEL_EVENT_ID,
+// SENTINEL_PRODUCT_ID and SENTINEL_FILE_PATH environment variables. Non-zero exit is a failure
+type Command struct {
+	Path    string
+	Args    []string
+	Env     []string      // additional environment, the daemon environment is inherited
+	Timeout time.Duration // 10m if zero
+}
+
+func (c Command) Name() string {
+	return "command " + c.Path
+}
+
+func (c Command) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error on marshal event: %s", err)
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), c.Env...)
+	cmd.Env = append(cmd.Env, "SENTINEL_EVENT_TYPE="+string(e.Type), "SENTINEL_EVENT_ID="+e.ID)
+	if e.Job != nil {
+		cmd.Env = append(cmd.Env, "SENTINEL_PRODUCT_ID="+e.Job.ProductID, "SENTINEL_FILE_PATH="+e.Job.FilePath)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 512 {
+			msg = msg[len(msg)-512:]
+		}
+		return fmt.Errorf("error on run %s: %s: %s", c.Path, err, msg)
+	}
+	return nil
+}
+
+// Spool writes each event to a JSON file in Dir, named <type>-<id>.json. Files appear atomically,
+// consumers may pick them up and delete them
+type Spool struct {
+	Dir string
+}
+
+func (s Spool) Name() string {
+	return "spool " + s.Dir
+}
+
+func (s Spool) Send(ctx context.Context, e Event) error {
+	body, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on marshal event: %s", err)
+	}
+	if err = os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("error on create spool directory: %s", err)
+	}
+	name := fmt.Sprintf("%s-%s.json", strings.ReplaceAll(string(e.Type), ".", "_"), e.ID)
+	// temporary file starts with a dot so consumers watching *.json do not see partial files
+	tmp := filepath.Join(s.Dir, "."+name+".tmp")
+	if err = os.WriteFile(tmp, body, 0o644); err != nil {
+		return fmt.Errorf("error on write event file: %s", err)
+	}
+	if err = os.Rename(tmp, filepath.Join(s.Dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error on move event file: %s", err)
+	}
+	return nil
+}
+
+// WatchFilter passes to Sink only events of Watch: its new products and downloads it queued
+type WatchFilter struct {
+	Watch string
+	Sink  Sink
+}
+
+func (f WatchFilter) Name() string {
+	return f.Sink.Name() + " of watch " + f.Watch
+}
+
+func (f WatchFilter) Send(ctx context.Context, e Event) error {
+	if e.Watch != f.Watch {
+		return nil
+	}
+	return f.Sink.Send(ctx, e)
+}
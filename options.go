@@ -0,0 +1,105 @@
+package sentinel
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+const defaultBaseURL = "https://scihub.copernicus.eu/dhus"
+
+// SearcherOption configures searcher created by NewSentinelSearcher
+type SearcherOption func(*sentinelSearcher)
+
+// WithBaseURL sets hub root URL, e.g. https://scihub.copernicus.eu/dhus.
+// Search requests are sent to <baseURL>/search
+func WithBaseURL(baseURL string) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.searchURL = strings.TrimRight(baseURL, "/") + "/search?q="
+	}
+}
+
+// WithPageSize sets number of rows requested per search page. Non-positive values are ignored
+func WithPageSize(rows int) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		if rows > 0 {
+			ss.rows = rows
+		}
+	}
+}
+
+// WithPageRetries sets attempts of a failed search page after the first one, waiting backoff before
+// the second attempt and doubling it for next ones. 5 attempts and 1s by default
+func WithPageRetries(attempts int, backoff time.Duration) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.pageAttempts = max(attempts, 1)
+		ss.pageBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets http client used for search requests
+func WithHTTPClient(c *http.Client) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		if c != nil {
+			ss.httpClient = c
+		}
+	}
+}
+
+// WithUserAgent sets User-Agent header of search requests
+func WithUserAgent(userAgent string) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.userAgent = userAgent
+	}
+}
+
+// WithTransport sets round tripper of http client, e.g. for proxy or TLS configuration.
+// Client provided with WithHTTPClient is not modified, its copy is used instead
+func WithTransport(rt http.RoundTripper) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.transport = rt
+	}
+}
+
+// WithLimiter makes searcher wait for the limiter before each request and pause when
+// the hub reports exceeded quota. Share one limiter between searcher and engines using the same account
+func WithLimiter(l *quota.Limiter) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.limiter = l
+	}
+}
+
+// WithLogger enables logging of search requests: URL without credentials, status,
+// bytes, latency and retries. Successful requests are logged at debug level
+func WithLogger(logger *slog.Logger) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.obs.Logger = logger
+	}
+}
+
+// WithTracer enables tracing spans around each search page
+func WithTracer(tracer telemetry.Tracer) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.obs.Tracer = tracer
+	}
+}
+
+// WithMetrics makes searcher report request, page and search metrics, see telemetry/prometheus
+func WithMetrics(metrics telemetry.Metrics) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.obs.Metrics = metrics
+	}
+}
+
+// WithCredentials makes searcher ask p for credentials of the hub host before each request,
+// instead of user and password given to NewSentinelSearcher
+func WithCredentials(p credentials.Provider) SearcherOption {
+	return func(ss *sentinelSearcher) {
+		ss.auth = p
+	}
+}
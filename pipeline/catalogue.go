@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Catalogue registers processed products, e.g. in a database. Register must update
+// product registered before, since runs may be repeated
+type Catalogue interface {
+	Register(ctx context.Context, p Product) error
+}
+
+// FileCatalogue is a Catalogue kept in a JSON file, products are keyed by ID
+type FileCatalogue struct {
+	filePath string
+
+	mu       sync.Mutex
+	products map[string]Product
+}
+
+// OpenCatalogue loads catalogue from filePath, the file is created on first change if it does not exist
+func OpenCatalogue(filePath string) (*FileCatalogue, error) {
+	c := &FileCatalogue{filePath: filePath, products: make(map[string]Product)}
+	bs, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error on read catalogue: %s", err)
+	}
+	if len(bs) > 0 {
+		if err = json.Unmarshal(bs, &c.products); err != nil {
+			return nil, fmt.Errorf("error on parse catalogue %s: %s", filePath, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *FileCatalogue) Register(ctx context.Context, p Product) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.products[p.ID] = p
+
+	bs, err := json.MarshalIndent(c.products, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on marshal catalogue: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(c.filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create catalogue directory: %s", err)
+	}
+	tmp := c.filePath + ".tmp"
+	if err = os.WriteFile(tmp, bs, 0o644); err != nil {
+		return fmt.Errorf("error on write catalogue: %s", err)
+	}
+	if err = os.Rename(tmp, c.filePath); err != nil {
+		return fmt.Errorf("error on replace catalogue: %s", err)
+	}
+	return nil
+}
+
+// Products returns registered products sorted by ID
+func (c *FileCatalogue) Products() []Product {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]Product, 0, len(c.products))
+	for _, p := range c.products {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
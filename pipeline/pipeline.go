@@ -0,0 +1,261 @@
+// Package pipeline runs processing steps on downloaded products: verify, extract, move to a
+// directory layout, write checksum sidecars, register in a catalogue or anything custom.
+// Completed steps are recorded per product, so a re-run after failure continues where it stopped.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+// Downloader downloads product into dst directory, e.g. sentinel.SentinelClient
+type Downloader interface {
+	Download(productID string, dst string) (string, error)
+}
+
+// entryDownloader is implemented by downloaders placing products by entry fields, e.g. sentinel.SentinelClient
+type entryDownloader interface {
+	DownloadEntry(entry sentinel.QueryEntryResponse, dst string) (string, error)
+}
+
+// Product is a product passed through steps. Steps update Path when they move the product
+type Product struct {
+	ID    string                      `json:"id"`
+	Entry sentinel.QueryEntryResponse `json:"entry"`
+	Path  string                      `json:"path"`            // zip file or extracted SAFE directory
+	Files []string                    `json:"files,omitempty"` // files made by steps, e.g. checksum sidecars
+}
+
+// clone returns copy of product a step may change without affecting p
+func (p Product) clone() Product {
+	p.Files = slices.Clone(p.Files)
+	return p
+}
+
+// Step is a processing step. Steps should tolerate being run again on their own output,
+// since a crash may happen before completion is recorded
+type Step interface {
+	Name() string
+	Run(ctx context.Context, p *Product) error
+}
+
+// StepResult is an outcome of a step
+type StepResult struct {
+	Step     string
+	Skipped  bool // completed by previous run
+	Err      error
+	Duration time.Duration
+}
+
+// Result is an outcome of pipeline run
+type Result struct {
+	Product Product
+	Steps   []StepResult
+}
+
+// Pipeline downloads products and runs steps on them
+type Pipeline struct {
+	downloader Downloader
+	steps      []Step
+	stateDir   string
+	logger     *slog.Logger
+}
+
+// Option configures Pipeline
+type Option func(*Pipeline)
+
+// WithStateDir sets directory of per product state files, <dst>/.pipeline by default
+func WithStateDir(dir string) Option {
+	return func(p *Pipeline) {
+		p.stateDir = dir
+	}
+}
+
+// WithLogger sets logger of steps
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Pipeline) {
+		p.logger = logger
+	}
+}
+
+// New returns pipeline downloading with d and running steps in order. Step names must be unique
+func New(d Downloader, steps []Step, opts ...Option) (*Pipeline, error) {
+	names := make(map[string]struct{}, len(steps))
+	for _, s := range steps {
+		if _, ok := names[s.Name()]; ok || s.Name() == stepDownload {
+			return nil, fmt.Errorf("duplicate step name %s", s.Name())
+		}
+		names[s.Name()] = struct{}{}
+	}
+	p := &Pipeline{
+		downloader: d,
+		steps:      steps,
+		logger:     slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+const stepDownload = "download"
+
+type state struct {
+	Product Product  `json:"product"`
+	Done    []string `json:"done"`
+}
+
+// Run downloads entry product into dst and runs steps. Steps completed by previous runs are skipped,
+// download is skipped too if the downloaded file is still in place. Failure of a step stops the run
+func (p *Pipeline) Run(ctx context.Context, entry sentinel.QueryEntryResponse, dst string) (Result, error) {
+	id := entry.GetID()
+	if id == "" {
+		id = entry.UUID
+	}
+	if id == "" {
+		return Result{}, fmt.Errorf("entry has no ID")
+	}
+	stateDir := p.stateDir
+	if stateDir == "" {
+		stateDir = filepath.Join(dst, ".pipeline")
+	}
+	statePath := filepath.Join(stateDir, id+".json")
+	st, err := loadState(statePath)
+	if err != nil {
+		return Result{}, err
+	}
+	if st.Product.ID == "" {
+		st.Product = Product{ID: id, Entry: entry}
+	}
+	res := Result{Product: st.Product}
+
+	if slices.Contains(st.Done, stepDownload) {
+		if _, err = os.Stat(st.Product.Path); err != nil {
+			// downloaded file is gone, start over
+			st = state{Product: Product{ID: id, Entry: entry}}
+		}
+	}
+	if slices.Contains(st.Done, stepDownload) {
+		res.Steps = append(res.Steps, StepResult{Step: stepDownload, Skipped: true})
+	} else {
+		if p.downloader == nil {
+			return res, fmt.Errorf("downloader is nil")
+		}
+		start := time.Now()
+		var filePath string
+		if ed, ok := p.downloader.(entryDownloader); ok {
+			filePath, err = ed.DownloadEntry(entry, dst)
+		} else {
+			filePath, err = p.downloader.Download(id, dst)
+		}
+		res.Steps = append(res.Steps, StepResult{Step: stepDownload, Err: err, Duration: time.Since(start)})
+		if err != nil {
+			return res, fmt.Errorf("error on download %s: %w", id, err)
+		}
+		st.Product.Path = filePath
+		st.Done = append(st.Done, stepDownload)
+		if err = saveState(statePath, st); err != nil {
+			return res, err
+		}
+	}
+
+	res.Product = st.Product
+	steps, err := p.process(ctx, &st, func() error { return saveState(statePath, st) })
+	res.Steps = append(res.Steps, steps...)
+	res.Product = st.Product
+	return res, err
+}
+
+// Process runs steps on product already on disk, e.g. from an archive. State is not recorded
+func (p *Pipeline) Process(ctx context.Context, product Product) (Result, error) {
+	st := state{Product: product}
+	steps, err := p.process(ctx, &st, func() error { return nil })
+	return Result{Product: st.Product, Steps: steps}, err
+}
+
+func (p *Pipeline) process(ctx context.Context, st *state, save func() error) ([]StepResult, error) {
+	results := make([]StepResult, 0, len(p.steps))
+	for _, s := range p.steps {
+		if slices.Contains(st.Done, s.Name()) {
+			results = append(results, StepResult{Step: s.Name(), Skipped: true})
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		log := p.logger.With(slog.String("step", s.Name()), slog.String("product", st.Product.ID))
+		start := time.Now()
+		// steps work on a copy, so failed step does not leave half-updated product
+		product := st.Product.clone()
+		err := s.Run(ctx, &product)
+		results = append(results, StepResult{Step: s.Name(), Err: err, Duration: time.Since(start)})
+		if err != nil {
+			log.Error("step failed", slog.String("error", err.Error()))
+			if isOptional(s) {
+				// not recorded as done, so the next run tries it again
+				continue
+			}
+			return results, fmt.Errorf("error on step %s of %s: %w", s.Name(), st.Product.ID, err)
+		}
+		log.Debug("step done", slog.String("path", product.Path), slog.Duration("duration", time.Since(start)))
+		st.Product = product
+		st.Done = append(st.Done, s.Name())
+		if err = save(); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Reset forgets completed steps of product, so the next run starts from download
+func (p *Pipeline) Reset(productID string, dst string) error {
+	stateDir := p.stateDir
+	if stateDir == "" {
+		stateDir = filepath.Join(dst, ".pipeline")
+	}
+	if err := os.Remove(filepath.Join(stateDir, productID+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error on remove pipeline state: %s", err)
+	}
+	return nil
+}
+
+func loadState(filePath string) (state, error) {
+	var st state
+	bs, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("error on read pipeline state: %s", err)
+	}
+	if err = json.Unmarshal(bs, &st); err != nil {
+		return st, fmt.Errorf("error on parse pipeline state %s: %s", filePath, err)
+	}
+	return st, nil
+}
+
+func saveState(filePath string, st state) error {
+	bs, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on marshal pipeline state: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create state directory: %s", err)
+	}
+	tmp := filePath + ".tmp"
+	if err = os.WriteFile(tmp, bs, 0o644); err != nil {
+		return fmt.Errorf("error on write pipeline state: %s", err)
+	}
+	if err = os.Rename(tmp, filePath); err != nil {
+		return fmt.Errorf("error on replace pipeline state: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/layout"
+)
+
+const testProductName = "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407"
+
+// zipDownloader writes zipped S2 product built from safe package fixtures
+type zipDownloader struct {
+	t     *testing.T
+	calls int
+}
+
+func (d *zipDownloader) Download(productID string, dst string) (string, error) {
+	d.calls++
+	files := map[string]string{
+		"manifest.safe":  filepath.Join("..", "safe", "testdata", "S2_manifest.safe"),
+		"MTD_MSIL2A.xml": filepath.Join("..", "safe", "testdata", "MTD_MSIL2A.xml"),
+	}
+	zipPath := filepath.Join(dst, testProductName+".zip")
+	out, err := os.Create(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	for name, src := range files {
+		bs, err := os.ReadFile(src)
+		if err != nil {
+			d.t.Fatal(err)
+		}
+		w, _ := zw.Create(testProductName + ".SAFE/" + name)
+		w.Write(bs)
+	}
+	w, _ := zw.Create(testProductName + ".SAFE/GRANULE/L2A_T36UYA_A034000_20220101T083343/IMG_DATA/R10m/T36UYA_20220101T083341_B04_10m.jp2")
+	w.Write([]byte("B04"))
+	return zipPath, zw.Close()
+}
+
+type verifier struct {
+	verified []string
+}
+
+func (v *verifier) Verify(productID string, filePath string) error {
+	v.verified = append(v.verified, productID)
+	return nil
+}
+
+func TestPipeline(t *testing.T) {
+	dst := t.TempDir()
+	archive := t.TempDir()
+	catalogue, err := OpenCatalogue(filepath.Join(dst, "catalogue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	dl := &zipDownloader{t: t}
+	v := &verifier{}
+	failures := 1
+	custom := Func("publish", func(ctx context.Context, p *Product) error {
+		if failures > 0 {
+			failures--
+			return errors.New("publisher is down")
+		}
+		return nil
+	})
+	notified := 0
+	optional := Optional(Func("notify", func(ctx context.Context, p *Product) error {
+		notified++
+		return errors.New("no listeners")
+	}))
+
+	p, err := New(dl, []Step{
+		Verify(v),
+		Extract(true),
+		Rename(archive, layout.MustParse("{platform}/{tile}/{year}/{month}/{identifier}.SAFE")),
+		ChecksumSidecar(),
+		optional,
+		custom,
+		Register(catalogue),
+	})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	entry := sentinel.QueryEntryResponse{ID: "uuid-1"}
+	res, err := p.Run(context.Background(), entry, dst)
+	if err == nil || !strings.Contains(err.Error(), "publisher is down") {
+		t.Fatalf("error should be of publish step, but is %v", err)
+	}
+	if len(res.Steps) != 7 || res.Steps[5].Err == nil || res.Steps[6].Step != "publish" {
+		t.Fatalf("unexpected step results %+v", res.Steps)
+	}
+
+	res, err = p.Run(context.Background(), entry, dst)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if dl.calls != 1 || len(v.verified) != 1 {
+		t.Errorf("completed steps should not be repeated, but download called %d times, verify %d times", dl.calls, len(v.verified))
+	}
+	if notified != 2 {
+		t.Errorf("failed optional step should be repeated, but was run %d times", notified)
+	}
+	for _, s := range res.Steps[:5] {
+		if !s.Skipped {
+			t.Errorf("step %s should be skipped", s.Step)
+		}
+	}
+
+	expected := filepath.Join(archive, "Sentinel-2", "36UYA", "2022", "01", testProductName+".SAFE")
+	if res.Product.Path != expected {
+		t.Errorf("product path should be %s, but is %s", expected, res.Product.Path)
+	}
+	if _, err = os.Stat(filepath.Join(expected, "manifest.safe")); err != nil {
+		t.Errorf("extracted product should be moved: %s", err)
+	}
+	if _, err = os.Stat(filepath.Join(dst, testProductName+".zip")); !os.IsNotExist(err) {
+		t.Errorf("archive should be removed")
+	}
+	bs, err := os.ReadFile(expected + ".sha256")
+	if err != nil || !strings.Contains(string(bs), testProductName+".SAFE/manifest.safe") {
+		t.Errorf("unexpected checksum sidecar %q, error %v", bs, err)
+	}
+	products := catalogue.Products()
+	if len(products) != 1 || products[0].Path != expected || products[0].Entry.TileId != "36UYA" {
+		t.Errorf("unexpected catalogue %+v", products)
+	}
+
+	// steps tolerate running on their own output
+	again, err := New(nil, []Step{
+		Extract(true),
+		Rename(archive, layout.MustParse("{platform}/{tile}/{year}/{month}/{identifier}.SAFE")),
+		ChecksumSidecar(),
+		Retry(optional, 1, 0), // stays optional when wrapped
+	})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	res, err = again.Process(context.Background(), res.Product)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if res.Product.Path != expected || len(res.Product.Files) != 1 {
+		t.Errorf("unexpected product after repeated steps %+v", res.Product)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	attempts := 0
+	step := Retry(Func("sidecar", func(ctx context.Context, p *Product) error {
+		attempts++
+		p.Files[0] = p.Path + ".tmp"
+		return errors.New("disk is busy")
+	}), 2, 0)
+
+	p := Product{Path: "product.zip", Files: []string{"product.zip.sha256"}}
+	if err := step.Run(context.Background(), &p); err == nil {
+		t.Fatalf("err is nil but should not be")
+	}
+	if attempts != 2 || p.Files[0] != "product.zip.sha256" {
+		t.Errorf("failed attempts should not change product, got %d attempts and %v", attempts, p.Files)
+	}
+}
@@ -0,0 +1,294 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/safe"
+)
+
+type funcStep struct {
+	name string
+	fn   func(ctx context.Context, p *Product) error
+}
+
+func (s funcStep) Name() string {
+	return s.name
+}
+
+func (s funcStep) Run(ctx context.Context, p *Product) error {
+	return s.fn(ctx, p)
+}
+
+// Func returns custom step
+func Func(name string, fn func(ctx context.Context, p *Product) error) Step {
+	return funcStep{name: name, fn: fn}
+}
+
+// optional is implemented by steps whose failure does not stop the pipeline. Wrappers of steps
+// forward it to the wrapped step
+type optional interface {
+	isOptional() bool
+}
+
+func isOptional(s Step) bool {
+	o, ok := s.(optional)
+	return ok && o.isOptional()
+}
+
+type optionalStep struct {
+	Step
+}
+
+// Optional makes failure of step not stop the pipeline. Failed step is run again on the next run
+func Optional(s Step) Step {
+	return optionalStep{Step: s}
+}
+
+func (s optionalStep) isOptional() bool {
+	return true
+}
+
+type retryStep struct {
+	Step
+	attempts int
+	backoff  time.Duration
+}
+
+// Retry runs step up to attempts times, waiting backoff between attempts
+func Retry(s Step, attempts int, backoff time.Duration) Step {
+	return retryStep{Step: s, attempts: max(attempts, 1), backoff: backoff}
+}
+
+func (s retryStep) isOptional() bool {
+	return isOptional(s.Step)
+}
+
+func (s retryStep) Run(ctx context.Context, p *Product) error {
+	var err error
+	for i := 0; i < s.attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(s.backoff):
+			}
+		}
+		product := p.clone()
+		if err = s.Step.Run(ctx, &product); err == nil {
+			*p = product
+			return nil
+		}
+	}
+	return err
+}
+
+// Verify checks downloaded archive against the hub checksum, e.g. with sentinel_engine.SentinelEngine
+func Verify(v safe.Verifier) Step {
+	return Func("verify", func(ctx context.Context, p *Product) error {
+		if info, err := os.Stat(p.Path); err != nil {
+			return fmt.Errorf("error on stat product: %s", err)
+		} else if info.IsDir() {
+			return fmt.Errorf("extracted product %s can not be verified", p.Path)
+		}
+		return v.Verify(p.ID, p.Path)
+	})
+}
+
+// Extract extracts zipped SAFE product next to the archive, removing the archive if removeArchive
+// is set. Empty fields of product entry are filled in from the product metadata
+func Extract(removeArchive bool) Step {
+	return Func("extract", func(ctx context.Context, p *Product) error {
+		info, err := os.Stat(p.Path)
+		if os.IsNotExist(err) {
+			// archive removed by interrupted run after extraction
+			target := strings.TrimSuffix(p.Path, filepath.Ext(p.Path)) + ".SAFE"
+			if _, serr := os.Stat(target); serr == nil {
+				p.Path = target
+				return nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error on stat product: %s", err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		sp, err := safe.Open(p.Path)
+		if err != nil {
+			return err
+		}
+		defer sp.Close()
+
+		dir := filepath.Dir(p.Path)
+		tmp, err := os.MkdirTemp(dir, ".extract-")
+		if err != nil {
+			return fmt.Errorf("error on create temporary directory: %s", err)
+		}
+		defer os.RemoveAll(tmp)
+		if _, err = sp.Extract(tmp); err != nil {
+			return err
+		}
+		name := sp.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(p.Path), filepath.Ext(p.Path)) + ".SAFE"
+		}
+		target := filepath.Join(dir, name)
+		// leftover of interrupted run
+		if err = os.RemoveAll(target); err != nil {
+			return fmt.Errorf("error on remove %s: %s", target, err)
+		}
+		if err = os.Rename(filepath.Join(tmp, name), target); err != nil {
+			return fmt.Errorf("error on move extracted product: %s", err)
+		}
+
+		p.Entry = merge(p.Entry, sp)
+		archive := p.Path
+		p.Path = target
+		if removeArchive {
+			if err = os.Remove(archive); err != nil {
+				return fmt.Errorf("error on remove archive: %s", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Rename moves product to root directory under relative path given by template,
+// e.g. {platform}/{tile}/{year}/{month}/{identifier}.SAFE
+func Rename(root string, t layout.Template) Step {
+	return Func("rename", func(ctx context.Context, p *Product) error {
+		rel, err := t.Path(p.Entry)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(root, rel)
+		if target == p.Path {
+			return nil
+		}
+		_, srcErr := os.Stat(p.Path)
+		if _, err = os.Stat(target); err == nil {
+			if os.IsNotExist(srcErr) {
+				// moved by interrupted run
+				p.Path = target
+				return nil
+			}
+			return fmt.Errorf("destination %s exists", target)
+		}
+		if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("error on create directory: %s", err)
+		}
+		if err = os.Rename(p.Path, target); err != nil {
+			return fmt.Errorf("error on move product: %s", err)
+		}
+		// files inside product moved with it, sidecars next to it are moved too
+		for i, f := range p.Files {
+			if !strings.HasPrefix(f, p.Path) {
+				continue
+			}
+			p.Files[i] = target + strings.TrimPrefix(f, p.Path)
+			if !strings.HasPrefix(f, p.Path+string(filepath.Separator)) {
+				if err = os.Rename(f, p.Files[i]); err != nil {
+					return fmt.Errorf("error on move %s: %s", f, err)
+				}
+			}
+		}
+		p.Path = target
+		return nil
+	})
+}
+
+// ChecksumSidecar writes SHA-256 of product to <path>.sha256 in sha256sum format. For extracted
+// products every file is listed with path relative to the SAFE directory parent
+func ChecksumSidecar() Step {
+	return Func("checksum", func(ctx context.Context, p *Product) error {
+		var lines strings.Builder
+		base := filepath.Dir(p.Path)
+		err := filepath.WalkDir(p.Path, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			sum, err := fileSHA256(filePath)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(base, filePath)
+			fmt.Fprintf(&lines, "%s  %s\n", sum, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error on compute checksum: %s", err)
+		}
+		sidecar := p.Path + ".sha256"
+		if err = os.WriteFile(sidecar, []byte(lines.String()), 0o644); err != nil {
+			return fmt.Errorf("error on write checksum sidecar: %s", err)
+		}
+		for _, f := range p.Files {
+			if f == sidecar {
+				return nil
+			}
+		}
+		p.Files = append(p.Files, sidecar)
+		return nil
+	})
+}
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// merge fills empty entry fields from SAFE metadata
+func merge(e sentinel.QueryEntryResponse, sp *safe.Product) sentinel.QueryEntryResponse {
+	m := sp.Entry()
+	if e.Identifier == "" {
+		e.Identifier = m.Identifier
+	}
+	if e.PlatformName == "" {
+		e.PlatformName = m.PlatformName
+	}
+	if e.ProductType == "" {
+		e.ProductType = m.ProductType
+	}
+	if e.TileId == "" {
+		e.TileId = m.TileId
+	}
+	if e.RelativeOrbitNumber == 0 {
+		e.RelativeOrbitNumber = m.RelativeOrbitNumber
+	}
+	if e.BeginPosition.IsZero() {
+		e.BeginPosition = m.BeginPosition
+	}
+	if e.EndPosition.IsZero() {
+		e.EndPosition = m.EndPosition
+	}
+	if e.Footprint == "" {
+		e.Footprint = m.Footprint
+	}
+	return e
+}
+
+// Register adds product to catalogue
+func Register(c Catalogue) Step {
+	return Func("register", func(ctx context.Context, p *Product) error {
+		return c.Register(ctx, *p)
+	})
+}
@@ -0,0 +1,265 @@
+// Package queue implements persistent download job queue and workers processing it.
+// Queue is stored in a JSON file rewritten on every change, so restarted service resumes its work.
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is a job status
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // waiting for a worker
+	StatusRunning   Status = "running"   // downloading
+	StatusTriggered Status = "triggered" // waiting for retrieval from long-term archive
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// IsFinal reports whether job with the status is not processed anymore
+func (s Status) IsFinal() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCanceled
+}
+
+// Job is a product download
+type Job struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"product_id"`
+	Identifier  string    `json:"identifier,omitempty"` // product name, if known
+	Dst         string    `json:"dst"`
+	Source      string    `json:"source,omitempty"` // e.g. saved search name the job comes from
+	Status      Status    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	FilePath    string    `json:"file_path,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	TriggeredAt time.Time `json:"triggered_at,omitempty"`
+}
+
+// ErrNotFound is returned for unknown job ID
+var ErrNotFound = errors.New("job not found")
+
+// Queue is a persistent job queue safe for concurrent use
+type Queue struct {
+	filePath string
+	now      func() time.Time
+
+	mu   sync.Mutex
+	jobs []*Job
+	byID map[string]*Job
+}
+
+type queueFile struct {
+	Jobs []*Job `json:"jobs"`
+}
+
+// Open loads queue from filePath, the file is created on first change if it does not exist.
+// Jobs interrupted while running are returned to pending
+func Open(filePath string) (*Queue, error) {
+	q := &Queue{
+		filePath: filePath,
+		now:      time.Now,
+		byID:     make(map[string]*Job),
+	}
+	bs, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error on read queue: %s", err)
+	}
+	if len(bs) > 0 {
+		var qf queueFile
+		if err = json.Unmarshal(bs, &qf); err != nil {
+			return nil, fmt.Errorf("error on parse queue %s: %s", filePath, err)
+		}
+		for _, j := range qf.Jobs {
+			if j.Status == StatusRunning {
+				j.Status = StatusPending
+			}
+			q.jobs = append(q.jobs, j)
+			q.byID[j.ID] = j
+		}
+	}
+	return q, nil
+}
+
+// save writes queue to temporary file renamed over the queue file, must be called with mu held
+func (q *Queue) save() error {
+	bs, err := json.MarshalIndent(queueFile{Jobs: q.jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on marshal queue: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(q.filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create queue directory: %s", err)
+	}
+	tmp := q.filePath + ".tmp"
+	if err = os.WriteFile(tmp, bs, 0o644); err != nil {
+		return fmt.Errorf("error on write queue: %s", err)
+	}
+	if err = os.Rename(tmp, q.filePath); err != nil {
+		return fmt.Errorf("error on replace queue: %s", err)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Add adds download job of product into dst. If the product is already queued and not
+// finished yet, the existing job is returned
+func (q *Queue) Add(productID string, dst string, identifier string, source string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.ProductID == productID && j.Dst == dst && !j.Status.IsFinal() {
+			return *j, nil
+		}
+	}
+	now := q.now()
+	j := &Job{
+		ID:         newID(),
+		ProductID:  productID,
+		Identifier: identifier,
+		Dst:        dst,
+		Source:     source,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	q.jobs = append(q.jobs, j)
+	q.byID[j.ID] = j
+	if err := q.save(); err != nil {
+		q.jobs = q.jobs[:len(q.jobs)-1]
+		delete(q.byID, j.ID)
+		return Job{}, err
+	}
+	return *j, nil
+}
+
+// Get returns job by ID
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.byID[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *j, nil
+}
+
+// List returns jobs in order they were added, only jobs with given statuses if any
+func (q *Queue) List(statuses ...Status) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	res := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		if len(statuses) == 0 || containsStatus(statuses, j.Status) {
+			res = append(res, *j)
+		}
+	}
+	return res
+}
+
+func containsStatus(statuses []Status, s Status) bool {
+	for _, status := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Next claims the job due earliest among pending and triggered ones and marks it running.
+// Returns false if no job is due
+func (q *Queue) Next() (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	due := make([]*Job, 0)
+	for _, j := range q.jobs {
+		if (j.Status == StatusPending || j.Status == StatusTriggered) && !j.NextAttempt.After(now) {
+			due = append(due, j)
+		}
+	}
+	if len(due) == 0 {
+		return Job{}, false, nil
+	}
+	sort.SliceStable(due, func(i, k int) bool {
+		return due[i].NextAttempt.Before(due[k].NextAttempt)
+	})
+	j := due[0]
+	j.Status = StatusRunning
+	j.UpdatedAt = now
+	return *j, true, q.save()
+}
+
+// Update stores changed job
+func (q *Queue) Update(job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.byID[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if j.Status == StatusCanceled {
+		// canceled while running, result is dropped
+		return nil
+	}
+	job.UpdatedAt = q.now()
+	*j = job
+	return q.save()
+}
+
+// Cancel cancels unfinished job. Running download is not interrupted but its result is ignored
+func (q *Queue) Cancel(id string) (Job, error) {
+	return q.transition(id, func(j *Job) error {
+		if j.Status.IsFinal() {
+			return fmt.Errorf("job %s is already %s", id, j.Status)
+		}
+		j.Status = StatusCanceled
+		return nil
+	})
+}
+
+// Retry returns failed or canceled job to pending, attempts are reset
+func (q *Queue) Retry(id string) (Job, error) {
+	return q.transition(id, func(j *Job) error {
+		if j.Status != StatusFailed && j.Status != StatusCanceled {
+			return fmt.Errorf("job %s is %s, only failed and canceled jobs can be retried", id, j.Status)
+		}
+		j.Status = StatusPending
+		j.Attempts = 0
+		j.Error = ""
+		j.NextAttempt = time.Time{}
+		j.TriggeredAt = time.Time{}
+		return nil
+	})
+}
+
+func (q *Queue) transition(id string, fn func(j *Job) error) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.byID[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if err := fn(j); err != nil {
+		return *j, err
+	}
+	j.UpdatedAt = q.now()
+	return *j, q.save()
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "state", "queue.json")
+	q, err := Open(filePath)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	a, err := q.Add("a", "/data", "", "api")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if dup, _ := q.Add("a", "/data", "", "api"); dup.ID != a.ID {
+		t.Errorf("queued product should not be added twice")
+	}
+	b, _ := q.Add("b", "/data", "", "api")
+
+	job, ok, err := q.Next()
+	if err != nil || !ok || job.ID != a.ID || job.Status != StatusRunning {
+		t.Fatalf("first job should be claimed, got %+v %t %v", job, ok, err)
+	}
+	if _, err = q.Cancel(b.ID); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if _, ok, _ = q.Next(); ok {
+		t.Errorf("no job should be due")
+	}
+
+	// restart: running job is resumed, canceled one is kept
+	q, err = Open(filePath)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if job, _ = q.Get(a.ID); job.Status != StatusPending {
+		t.Errorf("interrupted job should be pending, but is %s", job.Status)
+	}
+	if jobs := q.List(StatusCanceled); len(jobs) != 1 || jobs[0].ID != b.ID {
+		t.Errorf("unexpected canceled jobs %+v", jobs)
+	}
+	if _, err = q.Cancel(b.ID); err == nil {
+		t.Errorf("canceled job should not be canceled again")
+	}
+	if job, err = q.Retry(b.ID); err != nil || job.Status != StatusPending {
+		t.Errorf("canceled job should be retried, got %s %v", job.Status, err)
+	}
+	if _, err = q.Get("missing"); err != ErrNotFound {
+		t.Errorf("error should be ErrNotFound, but is %v", err)
+	}
+}
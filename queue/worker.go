@@ -0,0 +1,230 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	sentinel_engine "github.com/therox/go-sentinel/backend/sentinel"
+	"github.com/therox/go-sentinel/quota"
+)
+
+// Downloader downloads products, e.g. *sentinel.SentinelClient
+type Downloader interface {
+	Download(productID string, dst string) (string, error)
+	IsOnline(productID string) (bool, error)
+}
+
+// entryDownloader is implemented by downloaders placing products by entry fields, e.g. *sentinel.SentinelClient.
+// Engine with path layout finds already downloaded product by them without requesting the hub
+type entryDownloader interface {
+	DownloadEntry(entry sentinel.QueryEntryResponse, dst string) (string, error)
+}
+
+// Worker processes queued jobs: downloads online products, polls products retrieved from
+// long-term archive and retries failed downloads with backoff
+type Worker struct {
+	queue       *Queue
+	dl          Downloader
+	workers     int
+	idle        time.Duration
+	ltaPoll     time.Duration
+	ltaTimeout  time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	logger      *slog.Logger
+	onFinish    []func(Job)
+	now         func() time.Time
+}
+
+// WorkerOption configures Worker
+type WorkerOption func(*Worker)
+
+// WithWorkers sets number of concurrent downloads, 1 by default
+func WithWorkers(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.workers = n
+		}
+	}
+}
+
+// WithPollInterval sets how often idle workers look for due jobs, 5s by default
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.idle = d
+	}
+}
+
+// WithLTAPolling sets how often availability of products retrieved from long-term archive is
+// checked and how long to wait for them before the job fails. Defaults are 10m and 48h
+func WithLTAPolling(interval time.Duration, timeout time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.ltaPoll = interval
+		w.ltaTimeout = timeout
+	}
+}
+
+// WithRetries sets number of download attempts and backoff before the second one, doubled
+// for each next attempt. Defaults are 5 and 1m. Quota refusals do not count as attempts
+func WithRetries(maxAttempts int, backoff time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.maxAttempts = maxAttempts
+		w.backoff = backoff
+	}
+}
+
+// WithWorkerLogger sets logger of job progress
+func WithWorkerLogger(logger *slog.Logger) WorkerOption {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+// WithFinishHook adds function called when a job is done or failed
+func WithFinishHook(fn func(Job)) WorkerOption {
+	return func(w *Worker) {
+		w.onFinish = append(w.onFinish, fn)
+	}
+}
+
+// NewWorker returns worker processing jobs of q with dl
+func NewWorker(q *Queue, dl Downloader, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		queue:       q,
+		dl:          dl,
+		workers:     1,
+		idle:        5 * time.Second,
+		ltaPoll:     10 * time.Minute,
+		ltaTimeout:  48 * time.Hour,
+		maxAttempts: 5,
+		backoff:     time.Minute,
+		logger:      slog.New(slog.DiscardHandler),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run processes jobs until ctx is done. Running downloads are completed before it returns
+func (w *Worker) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		job, ok, err := w.queue.Next()
+		if err != nil {
+			w.logger.Error("error on claim job", slog.String("error", err.Error()))
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.idle):
+			}
+			continue
+		}
+		job = w.process(job)
+		if err = w.queue.Update(job); err != nil {
+			w.logger.Error("error on update job", slog.String("job", job.ID), slog.String("error", err.Error()))
+		}
+		if job.Status.IsFinal() {
+			for _, fn := range w.onFinish {
+				fn(job)
+			}
+		}
+	}
+}
+
+// process makes one attempt of the job and returns it with new status
+func (w *Worker) process(job Job) Job {
+	now := w.now()
+	log := w.logger.With(slog.String("job", job.ID), slog.String("product", job.ProductID))
+
+	if !job.TriggeredAt.IsZero() {
+		isOnline, err := w.dl.IsOnline(job.ProductID)
+		if err == nil && !isOnline {
+			if now.Sub(job.TriggeredAt) > w.ltaTimeout {
+				job.Status = StatusFailed
+				job.Error = fmt.Sprintf("product is not retrieved from long-term archive in %s", w.ltaTimeout)
+				log.Warn("retrieval timed out")
+				return job
+			}
+			job.Status = StatusTriggered
+			job.NextAttempt = now.Add(w.ltaPoll)
+			return job
+		}
+	}
+
+	if err := os.MkdirAll(job.Dst, 0o755); err != nil {
+		job.Status = StatusFailed
+		job.Error = fmt.Sprintf("error on create destination directory: %s", err)
+		return job
+	}
+	log.Info("download started")
+	var filePath string
+	var err error
+	if ed, ok := w.dl.(entryDownloader); ok && job.Identifier != "" {
+		filePath, err = ed.DownloadEntry(sentinel.QueryEntryResponse{ID: job.ProductID, UUID: job.ProductID, Identifier: job.Identifier}, job.Dst)
+	} else {
+		filePath, err = w.dl.Download(job.ProductID, job.Dst)
+	}
+
+	var ft sentinel_engine.ErrFileTriggered
+	var qe quota.ErrQuotaExceeded
+	switch {
+	case err == nil:
+		job.Status = StatusDone
+		job.FilePath = filePath
+		job.Error = ""
+		log.Info("download finished", slog.String("path", filePath))
+	case errors.As(err, &ft):
+		if job.TriggeredAt.IsZero() {
+			job.TriggeredAt = now
+		}
+		job.Status = StatusTriggered
+		job.NextAttempt = now.Add(w.ltaPoll)
+		log.Info("retrieval from long-term archive triggered")
+	case errors.As(err, &qe):
+		delay := qe.RetryAfter
+		if delay <= 0 {
+			delay = w.backoff
+		}
+		job.Status = StatusPending
+		job.Error = err.Error()
+		job.NextAttempt = now.Add(delay)
+		log.Warn("download postponed", slog.String("error", err.Error()), slog.Duration("delay", delay))
+	default:
+		job.Attempts++
+		job.Error = err.Error()
+		if job.Attempts >= w.maxAttempts {
+			job.Status = StatusFailed
+			log.Error("download failed", slog.String("error", err.Error()), slog.Int("attempts", job.Attempts))
+			return job
+		}
+		job.Status = StatusPending
+		job.NextAttempt = now.Add(w.backoff << (job.Attempts - 1))
+		log.Warn("download attempt failed", slog.String("error", err.Error()), slog.Int("attempts", job.Attempts))
+	}
+	return job
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentinel_engine "github.com/therox/go-sentinel/backend/sentinel"
+	"github.com/therox/go-sentinel/layout"
+	"github.com/therox/go-sentinel/sentineltest"
+	"github.com/therox/go-sentinel/storage"
+)
+
+func TestWorkerRetrieval(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Content: sentineltest.ProductContent(512)})
+	defer hub.Close()
+	se := sentinel_engine.NewSentinelEngine("user", "password", 0, sentinel_engine.WithBaseURL(hub.URL))
+
+	q, err := Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	dst := filepath.Join(t.TempDir(), "products")
+	job, _ := q.Add("id", dst, "", "api")
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := NewWorker(q, se, WithLTAPolling(time.Minute, time.Hour))
+	w.now = func() time.Time { return now }
+
+	if job = w.process(job); job.Status != StatusTriggered || !job.TriggeredAt.Equal(now) {
+		t.Fatalf("job should be triggered, but is %s", job.Status)
+	}
+	if !hub.Triggered("id") {
+		t.Errorf("retrieval should be triggered on the hub")
+	}
+	now = now.Add(time.Minute)
+	if job = w.process(job); job.Status != StatusTriggered || hub.Requests(sentineltest.EndpointDownload) != 1 {
+		t.Errorf("offline product should be polled without download")
+	}
+
+	hub.SetOnline("id", true)
+	if job = w.process(job); job.Status != StatusDone || job.FilePath == "" {
+		t.Errorf("job should be done, but is %s: %s", job.Status, job.Error)
+	}
+
+	hub.SetOnline("id", false)
+	job = Job{ID: job.ID, ProductID: "id", Dst: dst, TriggeredAt: now}
+	now = now.Add(2 * time.Hour)
+	if job = w.process(job); job.Status != StatusFailed {
+		t.Errorf("job should fail after retrieval timeout, but is %s", job.Status)
+	}
+}
+
+type failingDownloader struct {
+	calls int
+}
+
+func (f *failingDownloader) Download(productID string, dst string) (string, error) {
+	f.calls++
+	return "", errors.New("500:Internal Server Error")
+}
+
+func (f *failingDownloader) IsOnline(productID string) (bool, error) {
+	return true, nil
+}
+
+func TestWorkerRun(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	job, _ := q.Add("id", t.TempDir(), "", "api")
+
+	dl := &failingDownloader{}
+	finished := make(chan Job, 1)
+	w := NewWorker(q, dl, WithRetries(3, 0), WithPollInterval(time.Millisecond), WithFinishHook(func(j Job) {
+		finished <- j
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Run(ctx)
+	select {
+	case job = <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job is not finished")
+	}
+	cancel()
+	if job.Status != StatusFailed || job.Attempts != 3 || dl.calls != 3 {
+		t.Errorf("job should fail after 3 attempts, got %s after %d", job.Status, dl.calls)
+	}
+}
+
+func TestWorkerLayout(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Content: sentineltest.ProductContent(512), Online: true})
+	defer hub.Close()
+	se := sentinel_engine.NewSentinelEngine("user", "password", 0, sentinel_engine.WithBaseURL(hub.URL),
+		sentinel_engine.WithLayout(layout.MustParse("{tile}/{identifier}.zip")), sentinel_engine.WithCollision(storage.Skip))
+
+	q, err := Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	dst := t.TempDir()
+	identifier := "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407"
+	existing := filepath.Join(dst, "36UYA", identifier+".zip")
+	os.MkdirAll(filepath.Dir(existing), 0o755)
+	os.WriteFile(existing, []byte("zip"), 0o644)
+	job, _ := q.Add("id", dst, identifier, "api")
+
+	// product is found by the layout without requesting it from the hub
+	w := NewWorker(q, se)
+	if job = w.process(job); job.Status != StatusDone || job.FilePath != existing {
+		t.Errorf("job should be done with existing file, but is %s %s: %s", job.Status, job.FilePath, job.Error)
+	}
+	if n := hub.Requests(sentineltest.EndpointDownload); n != 0 {
+		t.Errorf("product should not be downloaded, but got %d requests", n)
+	}
+}
@@ -0,0 +1,200 @@
+package sentinel
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Optional engine capability to download product preview
+type quicklookDownloader interface {
+	DownloadQuicklook(productID string, dst string) (string, error)
+}
+
+// QuicklookURL returns link to product preview image, empty if not provided by the hub
+func (qer *QueryEntryResponse) QuicklookURL() string {
+	return qer.getLink("icon")
+}
+
+// AlternativeURL returns link to product OData entity, empty if not provided by the hub
+func (qer *QueryEntryResponse) AlternativeURL() string {
+	return qer.getLink("alternative")
+}
+
+func (qer *QueryEntryResponse) getLink(rel string) string {
+	for _, l := range qer.Link {
+		if l.Rel == rel {
+			return l.HREF
+		}
+	}
+	return ""
+}
+
+// DownloadQuicklook downloads product preview image into dst directory if download engine supports it
+func (c *SentinelClient) DownloadQuicklook(id string, dst string) (string, error) {
+	qd, ok := c.dlEngine.(quicklookDownloader)
+	if !ok {
+		return "", fmt.Errorf("download engine does not provide quicklooks")
+	}
+
+	return qd.DownloadQuicklook(id, dst)
+}
+
+// Known preview types, mime.ExtensionsByType gives .jfif for JPEG
+var quicklookExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// QuicklookFileName returns file name of product preview from Content-Disposition or
+// Content-Type response headers, <productID>.jpg if they are missing
+func QuicklookFileName(productID string, header http.Header) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(params["filename"]); name != "." && name != ".." && name != string(filepath.Separator) {
+			return name
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if ext, ok := quicklookExtensions[mediaType]; ok {
+		return productID + ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return productID + exts[0]
+	}
+	return productID + ".jpg"
+}
+
+// Optional searcher capability to GET hub links with its credentials
+type linkGetter interface {
+	get(link string) (*http.Response, error)
+}
+
+// downloadQuicklook downloads entry preview with the engine, or from entry icon link
+// if the engine does not provide quicklooks
+func (c *SentinelClient) downloadQuicklook(entry QueryEntryResponse, dst string) (filePath string, err error) {
+	if _, ok := c.dlEngine.(quicklookDownloader); ok {
+		return c.DownloadQuicklook(entry.GetID(), dst)
+	}
+	link := entry.QuicklookURL()
+	if link == "" {
+		return "", fmt.Errorf("entry %s has no quicklook link", entry.GetID())
+	}
+	get := http.Get
+	if lg, ok := c.Searcher.(linkGetter); ok {
+		get = lg.get
+	}
+	resp, err := get(link)
+	if err != nil {
+		return "", fmt.Errorf("error on GET quicklook: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%d:%s", resp.StatusCode, resp.Header.Get("Cause-Message"))
+	}
+
+	filePath = filepath.Join(dst, QuicklookFileName(entry.GetID(), resp.Header))
+	out, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error on create local file: %s", err)
+	}
+	_, err = io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", fmt.Errorf("error on saving quicklook: %s", err)
+	}
+	return filePath, nil
+}
+
+type QuicklookResult struct {
+	Entry QueryEntryResponse
+	Path  string
+	Err   error
+}
+
+// DownloadQuicklooks downloads previews of all entries into dst directory using given number of workers.
+// Engines without quicklooks are bypassed, previews are taken from icon links of entries then.
+// Results are in the order of entries.
+func (c *SentinelClient) DownloadQuicklooks(entries []QueryEntryResponse, dst string, workers int) []QuicklookResult {
+	results := make([]QuicklookResult, len(entries))
+	parallel(len(entries), workers, func(i int) {
+		results[i].Entry = entries[i]
+		results[i].Path, results[i].Err = c.downloadQuicklook(entries[i], dst)
+	})
+	return results
+}
+
+var contactSheetTemplate = template.Must(template.New("contactsheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Quicklooks</title>
+<style>
+body { font-family: sans-serif; }
+.item { display: inline-block; vertical-align: top; width: 260px; margin: 8px; font-size: 12px; word-wrap: break-word; }
+.item img { width: 256px; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+{{range .}}<div class="item">
+{{if .Src}}<img src="{{.Src}}" alt="{{.Entry.Identifier}}">{{else}}<p class="error">{{.Err}}</p>{{end}}
+<p>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</p>
+<p>Sensing: {{.Entry.BeginPosition.Format "2006-01-02 15:04:05"}}<br>
+Cloud cover: {{printf "%.1f" .Entry.CloudCoverPercentage}}%<br>
+{{if .Entry.TileId}}Tile: {{.Entry.TileId}}<br>{{end}}Size: {{.Entry.Size}}<br>
+UUID: {{.Entry.UUID}}</p>
+</div>
+{{end}}</body>
+</html>
+`))
+
+type contactSheetItem struct {
+	Entry QueryEntryResponse
+	Title string
+	Link  string
+	Src   string
+	Err   error
+}
+
+// WriteContactSheet writes HTML page listing quicklooks with key metadata.
+// Images are referenced relative to the page location.
+func WriteContactSheet(filePath string, results []QuicklookResult) error {
+	items := make([]contactSheetItem, len(results))
+	for i, r := range results {
+		items[i] = contactSheetItem{
+			Entry: r.Entry,
+			Title: r.Entry.Title,
+			Link:  r.Entry.AlternativeURL(),
+			Err:   r.Err,
+		}
+		if r.Err == nil && r.Path != "" {
+			src, err := filepath.Rel(filepath.Dir(filePath), r.Path)
+			if err != nil {
+				src = r.Path
+			}
+			items[i].Src = filepath.ToSlash(src)
+		}
+		if strings.TrimSpace(items[i].Title) == "" {
+			items[i].Title = r.Entry.Identifier
+		}
+	}
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error on create contact sheet: %s", err)
+	}
+	defer out.Close()
+
+	if err = contactSheetTemplate.Execute(out, items); err != nil {
+		return fmt.Errorf("error on render contact sheet: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sentinel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type quicklookDlEngine struct {
+	mockDlEngine
+}
+
+func (m quicklookDlEngine) DownloadQuicklook(productID string, dst string) (string, error) {
+	if productID == "missing" {
+		return "", fmt.Errorf("404:not found")
+	}
+	filePath := filepath.Join(dst, productID+".jpg")
+	return filePath, os.WriteFile(filePath, []byte("jpg"), 0o644)
+}
+
+func TestDownloadQuicklooks(t *testing.T) {
+	c, _ := NewClient(mockSentinelSearcher{}, quicklookDlEngine{})
+	dst := filepath.Join(t.TempDir(), "ql")
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	entries := []QueryEntryResponse{{ID: "a", Title: "A"}, {ID: "missing", Title: "M"}, {ID: "b", Title: "B"}}
+	results := c.DownloadQuicklooks(entries, dst, 2)
+	if len(results) != 3 || results[0].Err != nil || results[1].Err == nil || results[2].Path != filepath.Join(dst, "b.jpg") {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	sheet := filepath.Join(filepath.Dir(dst), "index.html")
+	if err := WriteContactSheet(sheet, results); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	bs, _ := os.ReadFile(sheet)
+	if !strings.Contains(string(bs), `src="ql/a.jpg"`) || !strings.Contains(string(bs), "404:not found") {
+		t.Errorf("unexpected contact sheet:\n%s", bs)
+	}
+
+	c, _ = NewClient(mockSentinelSearcher{}, mockDlEngine{})
+	if _, err := c.DownloadQuicklook("a", dst); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+}
+
+func TestDownloadQuicklooksIconLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "user" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpg"))
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(NewSentinelSearcher("user", "password"), mockDlEngine{})
+	entries := make([]QueryEntryResponse, 2)
+	json.Unmarshal([]byte(`{"id":"a","link":[{"rel":"icon","href":"`+srv.URL+`/Products('a')/Products('Quicklook')/$value"}]}`), &entries[0])
+	entries[1].ID = "b"
+	dst := t.TempDir()
+	results := c.DownloadQuicklooks(entries, dst, 1)
+	if results[0].Err != nil || results[0].Path != filepath.Join(dst, "a.jpg") {
+		t.Errorf("quicklook should be taken from icon link, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("entry without icon link should fail")
+	}
+}
@@ -0,0 +1,92 @@
+package quota
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is a kind of hub quota
+type Kind string
+
+const (
+	KindRequests   Kind = "requests"   // request rate
+	KindDownloads  Kind = "downloads"  // concurrent downloads per user
+	KindRetrievals Kind = "retrievals" // offline product retrievals from long-term archive per period
+)
+
+// ErrQuotaExceeded is returned when the hub refuses a request because user quota is exceeded
+type ErrQuotaExceeded struct {
+	Kind       Kind
+	StatusCode int
+	Message    string        // Cause-Message reported by the hub
+	RetryAfter time.Duration // zero if the hub did not tell
+}
+
+func (e ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("%d: %s quota exceeded: %s", e.StatusCode, e.Kind, e.Message)
+}
+
+// Keywords of DHuS and CDSE quota messages, e.g. "User 'u' offline products retrieval quota
+// exceeded (20 fetches max) trailing last 1 hours" or "Max number of concurrent downloads reached"
+var (
+	retrievalKeywords = []string{"offline", "retrieval", "fetches", "long term archive", "lta"}
+	downloadKeywords  = []string{"concurrent", "parallel", "simultaneous", "download"}
+	quotaKeywords     = []string{"quota", "too many", "limit", "exceeded", "max number"}
+)
+
+func containsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseResponse returns ErrQuotaExceeded if response reports exceeded quota and nil otherwise.
+// 429 is always a quota error, 403 only if its Cause-Message tells so
+func ParseResponse(resp *http.Response) error {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden) {
+		return nil
+	}
+	msg := resp.Header.Get("Cause-Message")
+	lower := strings.ToLower(msg)
+	if resp.StatusCode == http.StatusForbidden && !containsAny(lower, quotaKeywords) {
+		return nil
+	}
+	e := ErrQuotaExceeded{
+		Kind:       KindRequests,
+		StatusCode: resp.StatusCode,
+		Message:    msg,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	switch {
+	case containsAny(lower, retrievalKeywords):
+		e.Kind = KindRetrievals
+	case containsAny(lower, downloadKeywords):
+		e.Kind = KindDownloads
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+// parseRetryAfter parses Retry-After header given in seconds or as HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,261 @@
+// Package quota implements client-side limits matching Copernicus hub quotas: request rate,
+// concurrent downloads and offline product retrievals. One Limiter is meant to be shared by
+// searcher and engines using the same account.
+//
+// All Limiter methods are safe on nil Limiter and then do not limit anything.
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits requests with token bucket, concurrent downloads with semaphore and
+// long-term archive retrievals with sliding window counter
+type Limiter struct {
+	rate       float64 // tokens per second, 0 means unlimited
+	burst      float64
+	downloads  chan struct{}
+	ltaMax     int
+	ltaWindow  time.Duration
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	maxWait    time.Duration
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	lta         []time.Time
+}
+
+// Option configures Limiter
+type Option func(*Limiter)
+
+// WithRequestRate limits requests to perSecond on average with bursts of up to burst requests
+func WithRequestRate(perSecond float64, burst int) Option {
+	return func(l *Limiter) {
+		if burst < 1 {
+			burst = 1
+		}
+		l.rate = perSecond
+		l.burst = float64(burst)
+	}
+}
+
+// WithConcurrentDownloads limits number of simultaneous downloads. Non-positive n removes the limit
+func WithConcurrentDownloads(n int) Option {
+	return func(l *Limiter) {
+		l.downloads = nil
+		if n > 0 {
+			l.downloads = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRetrievals limits number of offline product retrievals triggered within period.
+// Non-positive n removes the limit
+func WithRetrievals(n int, period time.Duration) Option {
+	return func(l *Limiter) {
+		l.ltaMax = n
+		l.ltaWindow = period
+	}
+}
+
+// WithRetries sets how many times a request refused for quota is retried and the backoff
+// used when the hub does not send Retry-After. Backoff doubles on every retry up to maxBackoff
+func WithRetries(n int, backoff time.Duration, maxBackoff time.Duration) Option {
+	return func(l *Limiter) {
+		l.maxRetries = n
+		l.backoff = backoff
+		l.maxBackoff = maxBackoff
+	}
+}
+
+// WithMaxWait sets the longest pause accepted instead of failing with ErrQuotaExceeded.
+// Zero means no limit
+func WithMaxWait(d time.Duration) Option {
+	return func(l *Limiter) {
+		l.maxWait = d
+	}
+}
+
+// New returns limiter with defaults of Copernicus Open Access Hub: 2 concurrent downloads,
+// 20 retrievals per hour, no request rate limit and 5 retries with backoff from 10s to 5m
+func New(opts ...Option) *Limiter {
+	l := &Limiter{
+		downloads:  make(chan struct{}, 2),
+		ltaMax:     20,
+		ltaWindow:  time.Hour,
+		maxRetries: 5,
+		backoff:    10 * time.Second,
+		maxBackoff: 5 * time.Minute,
+		now:        time.Now,
+		sleep:      sleep,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.tokens = l.burst
+	return l
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Wait blocks until a request may be sent: limiter is not paused and a token is available
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		d := l.reserve()
+		if d <= 0 {
+			return nil
+		}
+		if err := l.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns zero or returns time to wait before trying again
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Pause holds all requests for d, e.g. after the hub answered 429
+func (l *Limiter) Pause(d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// AcquireDownload blocks until a download slot is free. Returned func releases the slot
+func (l *Limiter) AcquireDownload(ctx context.Context) (func(), error) {
+	if l == nil || l.downloads == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.downloads <- struct{}{}:
+	case <-ctx.Done():
+		return func() {}, ctx.Err()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.downloads })
+	}, nil
+}
+
+// RecordRetrieval counts retrieval of offline product triggered on the hub
+func (l *Limiter) RecordRetrieval() {
+	if l == nil || l.ltaMax <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lta = append(l.expireRetrievals(), l.now())
+}
+
+// expireRetrievals drops retrievals out of the window, must be called with mu held
+func (l *Limiter) expireRetrievals() []time.Time {
+	now := l.now()
+	i := 0
+	for i < len(l.lta) && now.Sub(l.lta[i]) >= l.ltaWindow {
+		i++
+	}
+	l.lta = l.lta[i:]
+	return l.lta
+}
+
+// RetrievalDelay returns zero if another retrieval may be triggered now or time until
+// the oldest retrieval leaves the window
+func (l *Limiter) RetrievalDelay() time.Duration {
+	if l == nil || l.ltaMax <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lta := l.expireRetrievals()
+	if len(lta) < l.ltaMax {
+		return 0
+	}
+	return lta[len(lta)-l.ltaMax].Add(l.ltaWindow).Sub(l.now())
+}
+
+// WaitRetrieval blocks until another retrieval may be triggered. It returns ErrQuotaExceeded
+// without waiting if the wait is longer than allowed by WithMaxWait
+func (l *Limiter) WaitRetrieval(ctx context.Context) error {
+	for {
+		d := l.RetrievalDelay()
+		if d <= 0 {
+			return nil
+		}
+		if l.maxWait > 0 && d > l.maxWait {
+			return ErrQuotaExceeded{Kind: KindRetrievals, StatusCode: 429, Message: "client-side retrieval limit reached", RetryAfter: d}
+		}
+		if err := l.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// retryDelay returns pause before retry attempt (starting with 0) of request refused with e,
+// false if the request should not be retried
+func (l *Limiter) retryDelay(e ErrQuotaExceeded, attempt int) (time.Duration, bool) {
+	if attempt >= l.maxRetries {
+		return 0, false
+	}
+	d := e.RetryAfter
+	if d <= 0 {
+		d = l.backoff << attempt
+		if l.maxBackoff > 0 && (d > l.maxBackoff || d <= 0) {
+			d = l.maxBackoff
+		}
+	}
+	if l.maxWait > 0 && d > l.maxWait {
+		return 0, false
+	}
+	return d, true
+}
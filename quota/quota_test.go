@@ -0,0 +1,146 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/therox/go-sentinel/sentineltest"
+)
+
+// fakeClock makes limiter sleep instantly, recording requested pauses
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) install(l *Limiter) *Limiter {
+	c.now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return c.now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+	return l
+}
+
+func TestParseResponse(t *testing.T) {
+	cases := []struct {
+		status  int
+		message string
+		kind    Kind
+		isQuota bool
+	}{
+		{429, "", KindRequests, true},
+		{429, "Max number of concurrent downloads reached", KindDownloads, true},
+		{403, "User 'user' offline products retrieval quota exceeded (20 fetches max) trailing last 1 hours.", KindRetrievals, true},
+		{403, "Unauthorized access", "", false},
+		{500, "Too many requests", "", false},
+	}
+	for _, c := range cases {
+		resp := &http.Response{StatusCode: c.status, Header: http.Header{}}
+		resp.Header.Set("Cause-Message", c.message)
+		resp.Header.Set("Retry-After", "30")
+		err := ParseResponse(resp)
+		var qe ErrQuotaExceeded
+		if errors.As(err, &qe) != c.isQuota {
+			t.Errorf("%d %q: unexpected error %v", c.status, c.message, err)
+			continue
+		}
+		if c.isQuota && (qe.Kind != c.kind || qe.RetryAfter != 30*time.Second) {
+			t.Errorf("%d %q: unexpected kind %s or retry after %s", c.status, c.message, qe.Kind, qe.RetryAfter)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	clock := &fakeClock{}
+	l := clock.install(New(WithRequestRate(2, 2), WithRetrievals(2, time.Hour), WithConcurrentDownloads(1)))
+
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("error should be nil, but is %s", err)
+		}
+	}
+	if len(clock.sleeps) != 2 || clock.sleeps[0] != 500*time.Millisecond {
+		t.Errorf("burst of 2 should pass, then wait 500ms per request, but waited %v", clock.sleeps)
+	}
+
+	l.RecordRetrieval()
+	clock.now = clock.now.Add(10 * time.Minute)
+	l.RecordRetrieval()
+	if d := l.RetrievalDelay(); d != 50*time.Minute {
+		t.Errorf("retrieval delay should be 50m, but is %s", d)
+	}
+	if err := l.WaitRetrieval(context.Background()); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if d := l.RetrievalDelay(); d != 0 {
+		t.Errorf("retrieval delay should be 0, but is %s", d)
+	}
+	l.RecordRetrieval()
+	l.maxWait = time.Minute
+	var qe ErrQuotaExceeded
+	if err := l.WaitRetrieval(context.Background()); !errors.As(err, &qe) || qe.Kind != KindRetrievals {
+		t.Errorf("error should be retrievals ErrQuotaExceeded, but is %v", err)
+	}
+
+	release, err := l.AcquireDownload(context.Background())
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = l.AcquireDownload(ctx); err == nil {
+		t.Errorf("second download should wait for the slot")
+	}
+	release()
+	release()
+	if release, err = l.AcquireDownload(context.Background()); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+	release()
+
+	var nl *Limiter
+	if err = nl.Wait(context.Background()); err != nil || nl.RetrievalDelay() != 0 {
+		t.Errorf("nil limiter should not limit")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "id", Online: true})
+	defer hub.Close()
+	clock := &fakeClock{}
+	l := clock.install(New(WithRetries(2, time.Second, time.Minute)))
+	c := &http.Client{Transport: l.Transport(nil)}
+
+	hub.FailNext(sentineltest.EndpointOnline, 429, 429)
+	resp, err := c.Get(hub.URL + "/odata/v1/Products('id')/Online/$value")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status should be 200, but is %d", resp.StatusCode)
+	}
+	if len(clock.sleeps) != 2 || clock.sleeps[0] != time.Second || clock.sleeps[1] != 2*time.Second {
+		t.Errorf("should pause 1s and 2s, but paused %v", clock.sleeps)
+	}
+
+	hub.FailNext(sentineltest.EndpointOnline, 429, 429, 429)
+	resp, err = c.Get(hub.URL + "/odata/v1/Products('id')/Online/$value")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	resp.Body.Close()
+	var qe ErrQuotaExceeded
+	if !errors.As(ParseResponse(resp), &qe) {
+		t.Errorf("refusal should be returned after retries")
+	}
+	if hub.Requests(sentineltest.EndpointOnline) != 6 {
+		t.Errorf("should be 6 requests, but got %d", hub.Requests(sentineltest.EndpointOnline))
+	}
+}
@@ -0,0 +1,63 @@
+package quota
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+// Transport returns round tripper waiting for the limiter before each request and pausing
+// all requests of the limiter when the hub reports exceeded quota. Refused requests are
+// retried, the last refusal is returned as is so callers can parse it with ParseResponse.
+// Nil rt means http.DefaultTransport
+func (l *Limiter) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if l == nil {
+		return rt
+	}
+	return transport{limiter: l, rt: rt}
+}
+
+type transport struct {
+	limiter *Limiter
+	rt      http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		r := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			// request with body can be resent only if it can be rewound
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		resp, err := t.rt.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		var qe ErrQuotaExceeded
+		if !errors.As(ParseResponse(resp), &qe) {
+			return resp, nil
+		}
+		d, isRetried := t.limiter.retryDelay(qe, attempt)
+		if !isRetried || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		telemetry.OperationFromContext(ctx).Retry(qe.Error())
+		t.limiter.Pause(d)
+	}
+}
@@ -0,0 +1,64 @@
+package safe
+
+import (
+	"strings"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+// Identifier returns product name without SAFE or SEN3 extension, as used in search results
+func (p *Product) Identifier() string {
+	return strings.TrimSuffix(strings.TrimSuffix(p.Name, ".SAFE"), ".SEN3")
+}
+
+// Entry returns product metadata in the form of search result entry. Hub specific fields
+// like ID and UUID are left empty.
+func (p *Product) Entry() sentinel.QueryEntryResponse {
+	m := p.Manifest
+	e := sentinel.QueryEntryResponse{
+		Title:                    p.Identifier(),
+		Identifier:               p.Identifier(),
+		FileName:                 p.Name,
+		PlatformName:             platformName(m.PlatformFamily),
+		PlatformSerialIdentifier: platformName(p.Platform),
+		PlatformIdentifier:       m.NSSDCIdentifier,
+		InstrumentName:           m.InstrumentName,
+		InstrumentShortName:      m.InstrumentShortName,
+		SensorOperationalMode:    m.InstrumentMode,
+		ProductType:              p.ProductType,
+		BeginPosition:            p.SensingStart,
+		EndPosition:              p.SensingStop,
+		OrbitNumber:              m.OrbitNumber,
+		RelativeOrbitNumber:      m.RelativeOrbitNumber,
+		OrbitDirection:           m.OrbitDirection,
+		TileId:                   p.Tile,
+		ProcessingBaseline:       p.ProcessingBaseline,
+		Footprint:                p.Footprint,
+	}
+	if md := p.Metadata; md != nil {
+		e.ProcessingLevel = md.ProcessingLevel
+		e.CloudCoverPercentage = md.CloudCoverPercentage
+		e.GenerationDate = md.GenerationTime
+		if md.SensingOrbitNumber > 0 {
+			e.RelativeOrbitNumber = md.SensingOrbitNumber
+		}
+		if md.SensingOrbitDirection != "" {
+			e.OrbitDirection = md.SensingOrbitDirection
+		}
+		if len(p.Granules) > 0 {
+			e.GranuleIdentifier = p.Granules[0]
+		}
+	}
+	if len(m.Polarisations) > 0 {
+		e.Attributes = map[string]string{"polarisationmode": strings.Join(m.Polarisations, " ")}
+	}
+	return e
+}
+
+// platformName converts SAFE platform name, e.g. SENTINEL-2A, to the form used by the hub, e.g. Sentinel-2A
+func platformName(name string) string {
+	if !strings.HasPrefix(name, "SENTINEL") {
+		return name
+	}
+	return "Sentinel" + strings.TrimPrefix(name, "SENTINEL")
+}
@@ -0,0 +1,23 @@
+package safe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FootprintWKT converts SAFE footprint coordinates to WKT polygon. Both "lat,lon lat,lon" (S1)
+// and "lat lon lat lon" (S2, S3) forms are accepted. Returns empty string if coordinates are invalid.
+func FootprintWKT(coordinates string) string {
+	values := strings.Fields(strings.ReplaceAll(coordinates, ",", " "))
+	if len(values) < 6 || len(values)%2 != 0 {
+		return ""
+	}
+	points := make([]string, 0, len(values)/2+1)
+	for i := 0; i < len(values); i += 2 {
+		points = append(points, fmt.Sprintf("%s %s", values[i+1], values[i]))
+	}
+	if points[0] != points[len(points)-1] {
+		points = append(points, points[0])
+	}
+	return fmt.Sprintf("POLYGON((%s))", strings.Join(points, ","))
+}
@@ -0,0 +1,174 @@
+package safe
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest is the XFDU manifest of SAFE product (manifest.safe of S1/S2, xfdumanifest.xml of S3)
+type Manifest struct {
+	PlatformFamily      string // SENTINEL-2
+	PlatformNumber      string // A
+	NSSDCIdentifier     string // 2015-028A
+	InstrumentName      string
+	InstrumentShortName string // MSI
+	OrbitNumber         int
+	RelativeOrbitNumber int
+	OrbitDirection      string
+	SensingStart        time.Time
+	SensingStop         time.Time
+	ProductType         string // S1 only, e.g. GRD
+	ProductClass        string // S1 only
+	Polarisations       []string
+	InstrumentMode      string
+	Footprint           string // GML coordinates as published in manifest, if any
+	DataObjects         []DataObject
+}
+
+// DataObject is a file listed in manifest
+type DataObject struct {
+	ID                string
+	Href              string // path relative to SAFE root
+	MimeType          string
+	Size              int64
+	ChecksumAlgorithm string
+	Checksum          string
+}
+
+type xmlManifest struct {
+	MetadataObjects []struct {
+		ID       string `xml:"ID,attr"`
+		Platform *struct {
+			NSSDCIdentifier string `xml:"nssdcIdentifier"`
+			FamilyName      string `xml:"familyName"`
+			Number          string `xml:"number"`
+			Instrument      struct {
+				FamilyName struct {
+					Abbreviation string `xml:"abbreviation,attr"`
+					Value        string `xml:",chardata"`
+				} `xml:"familyName"`
+				Mode string `xml:"extension>instrumentMode>mode"`
+			} `xml:"instrument"`
+		} `xml:"metadataWrap>xmlData>platform"`
+		OrbitReference *struct {
+			OrbitNumber         []string `xml:"orbitNumber"`
+			RelativeOrbitNumber []string `xml:"relativeOrbitNumber"`
+			Pass                string   `xml:"extension>orbitProperties>pass"`
+			OrbitDirection      string   `xml:"extension>orbitProperties>orbitDirection"`
+		} `xml:"metadataWrap>xmlData>orbitReference"`
+		AcquisitionPeriod *struct {
+			StartTime string `xml:"startTime"`
+			StopTime  string `xml:"stopTime"`
+		} `xml:"metadataWrap>xmlData>acquisitionPeriod"`
+		StandAloneProductInformation *struct {
+			ProductClass                    string   `xml:"productClass"`
+			ProductType                     string   `xml:"productType"`
+			TransmitterReceiverPolarisation []string `xml:"transmitterReceiverPolarisation"`
+		} `xml:"metadataWrap>xmlData>standAloneProductInformation"`
+		GeneralProductInformation *struct {
+			ProductType string `xml:"productType"`
+		} `xml:"metadataWrap>xmlData>generalProductInformation"` // S3
+		FrameSet *struct {
+			Coordinates []string `xml:"frame>footPrint>coordinates"` // S1
+			PosList     string   `xml:"footPrint>posList"`           // S3
+		} `xml:"metadataWrap>xmlData>frameSet"`
+	} `xml:"metadataSection>metadataObject"`
+	DataObjects []struct {
+		ID         string `xml:"ID,attr"`
+		ByteStream struct {
+			MimeType     string `xml:"mimeType,attr"`
+			Size         int64  `xml:"size,attr"`
+			FileLocation struct {
+				Href string `xml:"href,attr"`
+			} `xml:"fileLocation"`
+			Checksum struct {
+				Name  string `xml:"checksumName,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"checksum"`
+		} `xml:"byteStream"`
+	} `xml:"dataObjectSection>dataObject"`
+}
+
+// ParseManifest parses XFDU manifest
+func ParseManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	var x xmlManifest
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return m, fmt.Errorf("error on parse manifest: %s", err)
+	}
+
+	var err error
+	for _, mo := range x.MetadataObjects {
+		if p := mo.Platform; p != nil {
+			m.PlatformFamily = strings.TrimSpace(p.FamilyName)
+			m.PlatformNumber = strings.TrimSpace(p.Number)
+			m.NSSDCIdentifier = strings.TrimSpace(p.NSSDCIdentifier)
+			m.InstrumentName = strings.TrimSpace(p.Instrument.FamilyName.Value)
+			m.InstrumentShortName = strings.TrimSpace(p.Instrument.FamilyName.Abbreviation)
+			m.InstrumentMode = strings.TrimSpace(p.Instrument.Mode)
+		}
+		if o := mo.OrbitReference; o != nil {
+			if len(o.OrbitNumber) > 0 {
+				m.OrbitNumber, _ = strconv.Atoi(strings.TrimSpace(o.OrbitNumber[0]))
+			}
+			if len(o.RelativeOrbitNumber) > 0 {
+				m.RelativeOrbitNumber, _ = strconv.Atoi(strings.TrimSpace(o.RelativeOrbitNumber[0]))
+			}
+			m.OrbitDirection = strings.ToUpper(strings.TrimSpace(o.Pass + o.OrbitDirection))
+		}
+		if a := mo.AcquisitionPeriod; a != nil {
+			if m.SensingStart, err = parseTime(a.StartTime); err != nil {
+				return m, fmt.Errorf("error on parse sensing start: %s", err)
+			}
+			if m.SensingStop, err = parseTime(a.StopTime); err != nil {
+				return m, fmt.Errorf("error on parse sensing stop: %s", err)
+			}
+		}
+		if s := mo.StandAloneProductInformation; s != nil {
+			m.ProductClass = strings.TrimSpace(s.ProductClass)
+			m.ProductType = strings.TrimSpace(s.ProductType)
+			for _, p := range s.TransmitterReceiverPolarisation {
+				m.Polarisations = append(m.Polarisations, strings.TrimSpace(p))
+			}
+		}
+		if g := mo.GeneralProductInformation; g != nil {
+			m.ProductType = strings.TrimSpace(g.ProductType)
+		}
+		if f := mo.FrameSet; f != nil {
+			if len(f.Coordinates) > 0 {
+				m.Footprint = strings.TrimSpace(f.Coordinates[0])
+			} else {
+				m.Footprint = strings.TrimSpace(f.PosList)
+			}
+		}
+	}
+
+	for _, do := range x.DataObjects {
+		m.DataObjects = append(m.DataObjects, DataObject{
+			ID:                do.ID,
+			Href:              strings.TrimPrefix(strings.TrimSpace(do.ByteStream.FileLocation.Href), "./"),
+			MimeType:          do.ByteStream.MimeType,
+			Size:              do.ByteStream.Size,
+			ChecksumAlgorithm: do.ByteStream.Checksum.Name,
+			Checksum:          strings.TrimSpace(do.ByteStream.Checksum.Value),
+		})
+	}
+	return m, nil
+}
+
+// parseTime parses times of SAFE metadata, which may lack time zone designator
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05.999999999", s)
+}
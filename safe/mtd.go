@@ -0,0 +1,146 @@
+package safe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProductMetadata is the product level metadata of S2 products (MTD_MSIL1C.xml, MTD_MSIL2A.xml)
+type ProductMetadata struct {
+	ProductURI            string
+	ProductType           string // S2MSI2A
+	ProcessingLevel       string // Level-2A
+	ProcessingBaseline    string // 03.01
+	ProductStart          time.Time
+	ProductStop           time.Time
+	GenerationTime        time.Time
+	SpacecraftName        string // Sentinel-2A
+	DatatakeIdentifier    string
+	SensingOrbitNumber    int
+	SensingOrbitDirection string
+	ImageFiles            []string // paths relative to SAFE root, without extension
+	Footprint             string   // "lat lon" pairs as published in EXT_POS_LIST
+	CloudCoverPercentage  float64
+	QualityIndicators     map[string]string // leaf values of Quality_Indicators_Info by element name or check type
+}
+
+type xmlProductMetadata struct {
+	ProductInfo struct {
+		ProductStartTime   string `xml:"PRODUCT_START_TIME"`
+		ProductStopTime    string `xml:"PRODUCT_STOP_TIME"`
+		ProductURI         string `xml:"PRODUCT_URI"`
+		ProcessingLevel    string `xml:"PROCESSING_LEVEL"`
+		ProductType        string `xml:"PRODUCT_TYPE"`
+		ProcessingBaseline string `xml:"PROCESSING_BASELINE"`
+		GenerationTime     string `xml:"GENERATION_TIME"`
+		Datatake           struct {
+			Identifier            string `xml:"datatakeIdentifier,attr"`
+			SpacecraftName        string `xml:"SPACECRAFT_NAME"`
+			SensingOrbitNumber    string `xml:"SENSING_ORBIT_NUMBER"`
+			SensingOrbitDirection string `xml:"SENSING_ORBIT_DIRECTION"`
+		} `xml:"Datatake"`
+		ImageFiles []string `xml:"Product_Organisation>Granule_List>Granule>IMAGE_FILE"`
+	} `xml:"General_Info>Product_Info"`
+	FootprintPosList string `xml:"Geometric_Info>Product_Footprint>Product_Footprint>Global_Footprint>EXT_POS_LIST"`
+	CloudCoverage    string `xml:"Quality_Indicators_Info>Cloud_Coverage_Assessment"`
+}
+
+// ParseProductMetadata parses S2 product level metadata
+func ParseProductMetadata(r io.Reader) (ProductMetadata, error) {
+	var md ProductMetadata
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return md, fmt.Errorf("error on read product metadata: %s", err)
+	}
+
+	var x xmlProductMetadata
+	if err = xml.Unmarshal(bs, &x); err != nil {
+		return md, fmt.Errorf("error on parse product metadata: %s", err)
+	}
+	pi := x.ProductInfo
+	md.ProductURI = strings.TrimSpace(pi.ProductURI)
+	md.ProductType = strings.TrimSpace(pi.ProductType)
+	md.ProcessingLevel = strings.TrimSpace(pi.ProcessingLevel)
+	md.ProcessingBaseline = strings.TrimSpace(pi.ProcessingBaseline)
+	md.SpacecraftName = strings.TrimSpace(pi.Datatake.SpacecraftName)
+	md.DatatakeIdentifier = strings.TrimSpace(pi.Datatake.Identifier)
+	md.SensingOrbitNumber, _ = strconv.Atoi(strings.TrimSpace(pi.Datatake.SensingOrbitNumber))
+	md.SensingOrbitDirection = strings.TrimSpace(pi.Datatake.SensingOrbitDirection)
+	md.Footprint = strings.TrimSpace(x.FootprintPosList)
+	md.CloudCoverPercentage, _ = strconv.ParseFloat(strings.TrimSpace(x.CloudCoverage), 64)
+	for _, f := range pi.ImageFiles {
+		md.ImageFiles = append(md.ImageFiles, strings.TrimSpace(f))
+	}
+	for field, value := range map[*time.Time]string{
+		&md.ProductStart:   pi.ProductStartTime,
+		&md.ProductStop:    pi.ProductStopTime,
+		&md.GenerationTime: pi.GenerationTime,
+	} {
+		if *field, err = parseTime(value); err != nil {
+			return md, fmt.Errorf("error on parse product metadata time %s: %s", value, err)
+		}
+	}
+
+	md.QualityIndicators, err = leafValues(bs, "Quality_Indicators_Info")
+	if err != nil {
+		return md, fmt.Errorf("error on parse quality indicators: %s", err)
+	}
+	return md, nil
+}
+
+// leafValues collects text of leaf elements inside section element. Elements having checkType
+// attribute (quality checks) are keyed by its value, others by element name.
+func leafValues(bs []byte, section string) (map[string]string, error) {
+	res := make(map[string]string)
+	d := xml.NewDecoder(bytes.NewReader(bs))
+	depth := 0 // depth inside section, 0 if outside
+	var key string
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local == section {
+					depth = 1
+				}
+				continue
+			}
+			depth++
+			key = t.Name.Local
+			for _, a := range t.Attr {
+				if a.Name.Local == "checkType" {
+					key = a.Value
+				}
+			}
+			text.Reset()
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				continue
+			}
+			if key != "" {
+				if v := strings.TrimSpace(text.String()); v != "" {
+					res[key] = v
+				}
+				// parent elements are not leaves
+				key = ""
+			}
+			depth--
+		}
+	}
+}
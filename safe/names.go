@@ -0,0 +1,70 @@
+package safe
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reTile     = regexp.MustCompile(`_T(\d{2}[A-Z]{3})(_|\.|$)`)
+	reBaseline = regexp.MustCompile(`_N(\d{2})(\d{2})_`)
+	reBand     = regexp.MustCompile(`_(B\d[\dA]|TCI|SCL|AOT|WVP|PVI)(_(\d+)m)?$`)
+)
+
+// Native resolution of S2 bands in meters, used when file name has no resolution suffix (L1C)
+var bandResolutions = map[string]int{
+	"B01": 60, "B02": 10, "B03": 10, "B04": 10, "B05": 20, "B06": 20, "B07": 20,
+	"B08": 10, "B8A": 20, "B09": 60, "B10": 60, "B11": 20, "B12": 20, "TCI": 10,
+	"SCL": 20, "AOT": 10, "WVP": 10, "PVI": 320,
+}
+
+// TileFromName returns MGRS tile of S2 product or granule name, e.g. 36UYA
+func TileFromName(name string) string {
+	if m := reTile.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// BaselineFromName returns processing baseline of S2 product name, e.g. 03.01 for N0301
+func BaselineFromName(name string) string {
+	if m := reBaseline.FindStringSubmatch(name); m != nil {
+		return m[1] + "." + m[2]
+	}
+	return ""
+}
+
+// Band is an image file of S2 granule
+type Band struct {
+	Name       string // B04, SCL, TCI, ...
+	Resolution int    // meters
+	Granule    string // granule directory name
+	Path       string // path relative to SAFE root
+}
+
+// parseBand returns band of image file path relative to SAFE root
+func parseBand(filePath string) (Band, bool) {
+	if path.Ext(filePath) == "" {
+		filePath += ".jp2"
+	}
+	m := reBand.FindStringSubmatch(strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)))
+	if m == nil {
+		return Band{}, false
+	}
+	b := Band{Name: m[1], Path: filePath}
+	if m[3] != "" {
+		b.Resolution, _ = strconv.Atoi(m[3])
+	} else {
+		b.Resolution = bandResolutions[b.Name]
+	}
+	parts := strings.Split(filePath, "/")
+	for i := range parts {
+		if parts[i] == "GRANULE" && i+1 < len(parts) {
+			b.Granule = parts[i+1]
+			break
+		}
+	}
+	return b, true
+}
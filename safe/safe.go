@@ -0,0 +1,314 @@
+// Package safe reads Sentinel products in SAFE format (SEN3 for Sentinel-3), either zipped as downloaded
+// from the hub or extracted.
+package safe
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Product is an opened SAFE product
+type Product struct {
+	Name               string // SAFE directory name, e.g. S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407.SAFE
+	Platform           string // SENTINEL-2A
+	ProductType        string
+	SensingStart       time.Time
+	SensingStop        time.Time
+	Tile               string // S2 only
+	ProcessingBaseline string // S2 only
+	Footprint          string // WKT
+	Manifest           Manifest
+	Metadata           *ProductMetadata // S2 only
+	Granules           []string
+	Bands              []Band // S2 only
+
+	fsys   fs.FS
+	root   string
+	closer io.Closer
+}
+
+var manifestNames = []string{"manifest.safe", "xfdumanifest.xml"}
+
+// ErrNotSAFE is returned by Open for archives and directories having no product manifest
+var ErrNotSAFE = errors.New("manifest not found, not a SAFE product")
+
+// Open opens zipped SAFE product without extracting it
+func Open(zipPath string) (*Product, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("error on open zip: %s", err)
+	}
+	p, err := OpenFS(zr)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	p.closer = zr
+	return p, nil
+}
+
+// OpenDir opens extracted product, dir is SAFE directory, e.g. /data/S2A_MSIL2A_...SAFE
+func OpenDir(dir string) (*Product, error) {
+	p, err := OpenFS(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	p.Name = filepath.Base(dir)
+	return p, nil
+}
+
+// OpenFS opens SAFE product from file system having SAFE directory or its content at the root
+func OpenFS(fsys fs.FS) (*Product, error) {
+	root, manifestName, err := findRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+	p := &Product{
+		fsys: fsys,
+		root: root,
+	}
+	if root != "." {
+		p.Name = root
+	}
+
+	f, err := p.Open(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("error on open manifest: %s", err)
+	}
+	p.Manifest, err = ParseManifest(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	p.Platform = strings.TrimSpace(p.Manifest.PlatformFamily + p.Manifest.PlatformNumber)
+	p.ProductType = p.Manifest.ProductType
+	p.SensingStart = p.Manifest.SensingStart
+	p.SensingStop = p.Manifest.SensingStop
+	p.Footprint = FootprintWKT(p.Manifest.Footprint)
+
+	if err = p.readS2Metadata(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func findRoot(fsys fs.FS) (string, string, error) {
+	for _, name := range manifestNames {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			return ".", name, nil
+		}
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", "", fmt.Errorf("error on read product root: %s", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		for _, name := range manifestNames {
+			if _, err := fs.Stat(fsys, path.Join(e.Name(), name)); err == nil {
+				return e.Name(), name, nil
+			}
+		}
+	}
+	return "", "", ErrNotSAFE
+}
+
+func (p *Product) readS2Metadata() error {
+	entries, err := fs.ReadDir(p.fsys, p.root)
+	if err != nil {
+		return fmt.Errorf("error on read product root: %s", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "MTD_MSIL") || path.Ext(e.Name()) != ".xml" {
+			continue
+		}
+		f, err := p.Open(e.Name())
+		if err != nil {
+			return fmt.Errorf("error on open product metadata: %s", err)
+		}
+		md, err := ParseProductMetadata(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		p.Metadata = &md
+		break
+	}
+
+	imageFiles := make([]string, 0)
+	if p.Metadata != nil {
+		p.ProductType = p.Metadata.ProductType
+		p.ProcessingBaseline = p.Metadata.ProcessingBaseline
+		if !p.Metadata.ProductStart.IsZero() {
+			p.SensingStart = p.Metadata.ProductStart
+			p.SensingStop = p.Metadata.ProductStop
+		}
+		imageFiles = p.Metadata.ImageFiles
+		if footprint := FootprintWKT(p.Metadata.Footprint); footprint != "" {
+			p.Footprint = footprint
+		}
+	} else {
+		for _, do := range p.Manifest.DataObjects {
+			if strings.Contains(do.Href, "/IMG_DATA/") {
+				imageFiles = append(imageFiles, do.Href)
+			}
+		}
+	}
+
+	granules := make(map[string]struct{})
+	for _, f := range imageFiles {
+		if b, ok := parseBand(f); ok {
+			p.Bands = append(p.Bands, b)
+			if b.Granule != "" {
+				granules[b.Granule] = struct{}{}
+			}
+		}
+	}
+	for g := range granules {
+		p.Granules = append(p.Granules, g)
+	}
+	sort.Strings(p.Granules)
+
+	if p.Name == "" && p.Metadata != nil {
+		p.Name = p.Metadata.ProductURI
+	}
+	if p.ProcessingBaseline == "" {
+		p.ProcessingBaseline = BaselineFromName(p.Name)
+	}
+	p.Tile = TileFromName(p.Name)
+	if p.Tile == "" && len(p.Granules) > 0 {
+		p.Tile = TileFromName(p.Granules[0])
+	}
+	return nil
+}
+
+// Close releases underlying zip file
+func (p *Product) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
+
+// Open opens product file by slash separated path relative to SAFE root
+func (p *Product) Open(name string) (fs.File, error) {
+	return p.fsys.Open(path.Join(p.root, name))
+}
+
+// BandsByResolution returns band images of given resolution in meters
+func (p *Product) BandsByResolution(resolution int) []Band {
+	res := make([]Band, 0)
+	for _, b := range p.Bands {
+		if b.Resolution == resolution {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// QualityIndicators returns S2 quality indicators, e.g. Cloud_Coverage_Assessment, SENSOR_QUALITY
+func (p *Product) QualityIndicators() map[string]string {
+	if p.Metadata == nil {
+		return nil
+	}
+	return p.Metadata.QualityIndicators
+}
+
+// Files returns paths of all product files relative to SAFE root
+func (p *Product) Files() ([]string, error) {
+	files := make([]string, 0)
+	err := fs.WalkDir(p.fsys, p.root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p.relPath(filePath))
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (p *Product) relPath(filePath string) string {
+	if p.root == "." {
+		return filePath
+	}
+	return strings.TrimPrefix(filePath, p.root+"/")
+}
+
+// Match reports whether product file matches any of patterns. Pattern containing slash is matched
+// against path relative to SAFE root, e.g. GRANULE/*/IMG_DATA/R10m/*_B04_10m.jp2, otherwise against
+// file name, e.g. MTD_MSIL2A.xml. Empty patterns match any file.
+func Match(relPath string, patterns ...string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		target := path.Base(relPath)
+		if strings.Contains(p, "/") {
+			target = relPath
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Extract extracts product files matching any of patterns (see Match) into dst, preserving
+// SAFE directory layout. Returns local paths of extracted files.
+func (p *Product) Extract(dst string, patterns ...string) ([]string, error) {
+	files, err := p.Files()
+	if err != nil {
+		return nil, fmt.Errorf("error on list product files: %s", err)
+	}
+	filePaths := make([]string, 0)
+	for _, f := range files {
+		if !Match(f, patterns...) {
+			continue
+		}
+		filePath := filepath.Join(dst, p.Name, filepath.FromSlash(f))
+		if err = p.extractFile(f, filePath); err != nil {
+			return filePaths, err
+		}
+		filePaths = append(filePaths, filePath)
+	}
+	return filePaths, nil
+}
+
+func (p *Product) extractFile(name string, filePath string) error {
+	in, err := p.Open(name)
+	if err != nil {
+		return fmt.Errorf("error on open %s: %s", name, err)
+	}
+	defer in.Close()
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create local directory: %s", err)
+	}
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error on create local file: %s", err)
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return fmt.Errorf("error on extract %s: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package safe
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testProductName = "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407.SAFE"
+
+// writeTestZip builds zipped S2 L2A product from testdata fixtures
+func writeTestZip(t *testing.T) string {
+	t.Helper()
+	manifest, err := os.ReadFile(filepath.Join("testdata", "S2_manifest.safe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtd, err := os.ReadFile(filepath.Join("testdata", "MTD_MSIL2A.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	granule := "GRANULE/L2A_T36UYA_A034000_20220101T083343/IMG_DATA/"
+	files := map[string][]byte{
+		"manifest.safe":  manifest,
+		"MTD_MSIL2A.xml": mtd,
+		granule + "R10m/T36UYA_20220101T083341_B04_10m.jp2": []byte("B04"),
+		granule + "R10m/T36UYA_20220101T083341_TCI_10m.jp2": []byte("TCI"),
+		granule + "R20m/T36UYA_20220101T083341_SCL_20m.jp2": []byte("SCL"),
+		granule + "R20m/T36UYA_20220101T083341_B8A_20m.jp2": []byte("B8A"),
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "product.zip")
+	out, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	for name, content := range files {
+		w, err := zw.Create(testProductName + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestOpen(t *testing.T) {
+	p, err := Open(writeTestZip(t))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	defer p.Close()
+
+	if p.Name != testProductName || p.Platform != "SENTINEL-2A" || p.ProductType != "S2MSI2A" {
+		t.Errorf("unexpected product %s %s %s", p.Name, p.Platform, p.ProductType)
+	}
+	if p.Tile != "36UYA" || p.ProcessingBaseline != "03.01" {
+		t.Errorf("unexpected tile %s or baseline %s", p.Tile, p.ProcessingBaseline)
+	}
+	if !p.SensingStart.Equal(time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC)) {
+		t.Errorf("unexpected sensing start %s", p.SensingStart)
+	}
+	if p.Manifest.RelativeOrbitNumber != 21 || len(p.Manifest.DataObjects) != 2 {
+		t.Errorf("unexpected manifest %+v", p.Manifest)
+	}
+	if p.Metadata.CloudCoverPercentage != 12.345 {
+		t.Errorf("unexpected cloud cover %f", p.Metadata.CloudCoverPercentage)
+	}
+	qi := p.QualityIndicators()
+	if qi["SENSOR_QUALITY"] != "PASSED" || qi["NODATA_PIXEL_PERCENTAGE"] != "3.5" || qi["Technical_Quality_Assessment"] != "" {
+		t.Errorf("unexpected quality indicators %v", qi)
+	}
+	if len(p.Granules) != 1 || len(p.BandsByResolution(10)) != 2 || len(p.BandsByResolution(20)) != 2 {
+		t.Errorf("unexpected granules %v or bands %v", p.Granules, p.Bands)
+	}
+
+	dst := t.TempDir()
+	filePaths, err := p.Extract(dst, "*_B04_10m.jp2", "MTD_MSIL2A.xml")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(filePaths) != 2 {
+		t.Fatalf("unexpected extracted files %v", filePaths)
+	}
+	bs, err := os.ReadFile(filepath.Join(dst, testProductName, "GRANULE", "L2A_T36UYA_A034000_20220101T083343", "IMG_DATA", "R10m", "T36UYA_20220101T083341_B04_10m.jp2"))
+	if err != nil || string(bs) != "B04" {
+		t.Errorf("unexpected extracted content %s: %v", bs, err)
+	}
+}
+
+func TestParseBand(t *testing.T) {
+	for name, expected := range map[string]string{
+		"GRANULE/L1C_T36UYA_A034000_20220101T083343/IMG_DATA/T36UYA_20220101T083341_B01": "B01:60",
+		"GRANULE/L2A_T36UYA/IMG_DATA/R60m/T36UYA_20220101T083341_AOT_60m.jp2":            "AOT:60",
+		"GRANULE/L2A_T36UYA/QI_DATA/MSK_CLDPRB_20m.jp2":                                  "",
+	} {
+		b, ok := parseBand(name)
+		actual := ""
+		if ok {
+			actual = fmt.Sprintf("%s:%d", b.Name, b.Resolution)
+		}
+		if actual != expected {
+			t.Errorf("band of %s should be %s, but is %s", name, expected, actual)
+		}
+	}
+}
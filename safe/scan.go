@@ -0,0 +1,134 @@
+package safe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+// Record is a product found by Scan
+type Record struct {
+	Path     string // zip file or SAFE directory
+	Entry    sentinel.QueryEntryResponse
+	Verified bool  // product checksum matches the hub one
+	Err      error // error on reading or verifying product
+}
+
+// Verifier checks local product file against the hub checksum, e.g. sentinel_engine.SentinelEngine
+type Verifier interface {
+	Verify(productID string, filePath string) error
+}
+
+type scanner struct {
+	searcher sentinel.ISentinelSearcher
+	verifier Verifier
+}
+
+// ScanOption configures Scan
+type ScanOption func(*scanner)
+
+// WithVerification makes Scan look up zipped products on the hub by file name and verify
+// them against the hub checksum. Extracted products can not be verified.
+func WithVerification(searcher sentinel.ISentinelSearcher, verifier Verifier) ScanOption {
+	return func(s *scanner) {
+		s.searcher = searcher
+		s.verifier = verifier
+	}
+}
+
+// IsProductPath reports whether path looks like Sentinel product: zip file or SAFE/SEN3 directory
+func IsProductPath(path string, isDir bool) bool {
+	ext := strings.ToUpper(filepath.Ext(path))
+	if isDir {
+		return ext == ".SAFE" || ext == ".SEN3"
+	}
+	return ext == ".ZIP"
+}
+
+// Scan walks directory tree and reads metadata of all products found. Zip files which are not
+// SAFE products are skipped, other errors on reading products are reported in records.
+func Scan(root string, opts ...ScanOption) ([]Record, error) {
+	s := scanner{}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	records := make([]Record, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !IsProductPath(path, d.IsDir()) {
+			return nil
+		}
+
+		rec, ok := s.scanProduct(path, d.IsDir())
+		if ok {
+			records = append(records, rec)
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return records, fmt.Errorf("error on walk %s: %s", root, err)
+	}
+	return records, nil
+}
+
+func (s scanner) scanProduct(path string, isDir bool) (Record, bool) {
+	rec := Record{Path: path}
+	var p *Product
+	var err error
+	if isDir {
+		p, err = OpenDir(path)
+	} else {
+		p, err = Open(path)
+	}
+	if err != nil {
+		// unrelated zip archives are not reported, broken ones are
+		rec.Err = err
+		return rec, isDir || !errors.Is(err, ErrNotSAFE)
+	}
+	rec.Entry = p.Entry()
+	p.Close()
+
+	if !isDir {
+		if fi, err := os.Stat(path); err == nil {
+			rec.Entry.ContentLength = fi.Size()
+		}
+	}
+	if s.verifier == nil || isDir {
+		return rec, true
+	}
+
+	if rec.Err = s.lookup(&rec.Entry); rec.Err != nil {
+		return rec, true
+	}
+	if rec.Err = s.verifier.Verify(rec.Entry.UUID, path); rec.Err == nil {
+		rec.Verified = true
+	}
+	return rec, true
+}
+
+// lookup sets ID and UUID of the entry found on the hub by product file name
+func (s scanner) lookup(entry *sentinel.QueryEntryResponse) error {
+	qr, err := s.searcher.Query(sentinel.SearchParameters{Filenames: []string{entry.FileName}})
+	if err != nil {
+		return fmt.Errorf("error on search %s: %s", entry.FileName, err)
+	}
+	for _, e := range qr.Feed.Entries {
+		if e.Identifier == entry.Identifier || e.FileName == entry.FileName {
+			entry.ID = e.ID
+			entry.UUID = e.UUID
+			return nil
+		}
+	}
+	return fmt.Errorf("product %s not found on the hub", entry.Identifier)
+}
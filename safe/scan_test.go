@@ -0,0 +1,98 @@
+package safe
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+type testSearcher struct{}
+
+func (testSearcher) Query(params sentinel.SearchParameters) (sentinel.QueryResponse, error) {
+	var qr sentinel.QueryResponse
+	if len(params.Filenames) == 1 && params.Filenames[0] == testProductName {
+		qr.Feed.Entries = []sentinel.QueryEntryResponse{{ID: "uuid", UUID: "uuid", FileName: testProductName}}
+	}
+	return qr, nil
+}
+
+type testVerifier map[string]string
+
+func (v testVerifier) Verify(productID string, filePath string) error {
+	v[productID] = filePath
+	return nil
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "archive", "2022", "product.zip")
+	os.MkdirAll(filepath.Dir(zipPath), 0o755)
+	bs, _ := os.ReadFile(writeTestZip(t))
+	os.WriteFile(zipPath, bs, 0o644)
+
+	p, err := Open(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Extract(filepath.Join(root, "extracted")); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	out, _ := os.Create(filepath.Join(root, "unrelated.zip"))
+	zw := zip.NewWriter(out)
+	zw.Create("readme.txt")
+	zw.Close()
+	out.Close()
+
+	verifier := testVerifier{}
+	records, err := Scan(root, WithVerification(testSearcher{}, verifier))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("should be 2 records, but got %d: %+v", len(records), records)
+	}
+	for _, rec := range records {
+		e := rec.Entry
+		if e.Identifier != "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407" || e.TileId != "36UYA" ||
+			e.PlatformName != "Sentinel-2" || e.PlatformSerialIdentifier != "Sentinel-2A" || e.CloudCoverPercentage != 12.345 {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	}
+	// walk order is lexical: archive before extracted
+	if !records[0].Verified || records[0].Entry.UUID != "uuid" || verifier["uuid"] != zipPath {
+		t.Errorf("zipped product should be verified: %+v", records[0])
+	}
+	if records[1].Verified || records[1].Err != nil {
+		t.Errorf("extracted product should not be verified: %+v", records[1])
+	}
+}
+
+func TestScanBrokenZip(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "broken.zip"), []byte("not a zip"), 0o644)
+
+	records, err := Scan(root)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(records) != 1 || records[0].Err == nil {
+		t.Errorf("broken zip should be reported: %+v", records)
+	}
+}
+
+func TestFootprintWKT(t *testing.T) {
+	for coords, expected := range map[string]string{
+		"50.5,35.8 50.4,37.3 49.4,37.2 49.5,35.7": "POLYGON((35.8 50.5,37.3 50.4,37.2 49.4,35.7 49.5,35.8 50.5))",
+		"50.5 35.8 50.4 37.3 49.4 37.2 50.5 35.8": "POLYGON((35.8 50.5,37.3 50.4,37.2 49.4,35.8 50.5))",
+		"50.5 35.8": "",
+	} {
+		if actual := FootprintWKT(coords); actual != expected {
+			t.Errorf("footprint of %s should be %s, but is %s", coords, expected, actual)
+		}
+	}
+}
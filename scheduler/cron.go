@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// every is a fixed interval schedule, "@every 2h"
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cron is a 5 field cron schedule: minute hour day-of-month month day-of-week
+type cron struct {
+	minute, hour, dom, month, dow uint64 // bit sets of allowed values
+	isDomAny, isDowAny            bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses standard 5 field cron expression, e.g. "0 */2 * * *", one of
+// @yearly, @monthly, @weekly, @daily, @hourly descriptors or "@every <duration>", e.g. "@every 2h"
+func ParseSchedule(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("incorrect schedule %s: interval should be at least 1m", expr)
+		}
+		return every(d), nil
+	}
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("incorrect schedule %s: 5 fields expected", expr)
+	}
+	var c cron
+	var err error
+	bounds := [5][2]uint{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		if *sets[i], err = parseField(field, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("incorrect schedule %s: %s", expr, err)
+		}
+	}
+	// 7 is Sunday as well as 0
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.isDomAny = fields[2] == "*"
+	c.isDowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parseField parses comma separated list of "*", "n", "a-b" with optional "/step"
+func parseField(field string, min uint, max uint) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := uint64(1)
+		if hasStep {
+			s, err := strconv.ParseUint(stepStr, 10, 8)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("incorrect step %s", part)
+			}
+			step = s
+		}
+		lo, hi := uint64(min), uint64(max)
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.ParseUint(a, 10, 8); err != nil {
+				return 0, fmt.Errorf("incorrect value %s", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.ParseUint(b, 10, 8); err != nil {
+					return 0, fmt.Errorf("incorrect value %s", part)
+				}
+			} else if hasStep {
+				hi = uint64(max)
+			}
+		}
+		if lo < uint64(min) || hi > uint64(max) || lo > hi {
+			return 0, fmt.Errorf("value %s is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// dayMatches applies cron rule: if both day of month and day of week are restricted,
+// a day matching either of them matches
+func (c cron) dayMatches(t time.Time) bool {
+	domOK := has(c.dom, t.Day())
+	dowOK := has(c.dow, int(t.Weekday()))
+	if c.isDomAny || c.isDowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// every valid expression matches within 5 years, e.g. February 29 on Monday
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(c.hour, t.Hour()):
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			if !next.After(t) {
+				// repeated hour at the end of daylight saving time
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ParseDuration parses duration as time.ParseDuration does, additionally accepting days, e.g. "3d" or "1d12h"
+func ParseDuration(s string) (time.Duration, error) {
+	var days time.Duration
+	if i := strings.Index(s, "d"); i > 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("incorrect duration %s", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("incorrect duration %s", s)
+	}
+	return days + d, nil
+}
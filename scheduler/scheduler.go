@@ -0,0 +1,188 @@
+// Package scheduler runs saved searches (watches) on cron schedules and hands products not seen
+// before to handlers: enqueue them for download, publish them to notify sinks or call a function.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+)
+
+// Handler handles new entries found by a watch. If a handler fails, entries are handled again
+// on the next run by it and the handlers after it, handlers succeeded before do not get them again
+type Handler func(ctx context.Context, w Watch, entries []sentinel.QueryEntryResponse) error
+
+// Scheduler runs watches
+type Scheduler struct {
+	searcher sentinel.ISentinelSearcher
+	store    SeenStore
+	watches  []Watch
+	handlers []Handler
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// Option configures Scheduler
+type Option func(*Scheduler)
+
+// WithHandler adds handler of new entries. Seen store remembers entries per handler by the order
+// handlers are added in
+func WithHandler(h Handler) Option {
+	return func(s *Scheduler) {
+		s.handlers = append(s.handlers, h)
+	}
+}
+
+// WithLogger sets logger of watch runs
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// New returns scheduler of watches searching with searcher. Watches are validated
+func New(searcher sentinel.ISentinelSearcher, store SeenStore, watches []Watch, opts ...Option) (*Scheduler, error) {
+	if searcher == nil {
+		return nil, fmt.Errorf("searcher is nil")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("seen store is nil")
+	}
+	s := &Scheduler{
+		searcher: searcher,
+		store:    store,
+		watches:  make([]Watch, len(watches)),
+		logger:   slog.New(slog.DiscardHandler),
+		now:      time.Now,
+	}
+	copy(s.watches, watches)
+	for i := range s.watches {
+		if err := s.watches[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Run runs watches on their schedules until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.watches) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	next := make([]time.Time, len(s.watches))
+	for i, w := range s.watches {
+		next[i] = w.schedule.Next(s.now())
+	}
+	for {
+		earliest := 0
+		for i := range next {
+			if next[i].Before(next[earliest]) {
+				earliest = i
+			}
+		}
+		timer := time.NewTimer(time.Until(next[earliest]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		now := s.now()
+		for i, w := range s.watches {
+			if next[i].After(now) {
+				continue
+			}
+			if _, err := s.RunWatch(ctx, w); err != nil {
+				s.logger.Error("watch run failed", slog.String("watch", w.Name), slog.String("error", err.Error()))
+			}
+			next[i] = w.schedule.Next(now)
+		}
+	}
+}
+
+// RunWatch runs watch once and returns entries not seen before
+func (s *Scheduler) RunWatch(ctx context.Context, w Watch) ([]sentinel.QueryEntryResponse, error) {
+	qr, err := s.searcher.Query(w.Parameters(s.now()))
+	if err != nil {
+		return nil, fmt.Errorf("error on search: %s", err)
+	}
+	byID := make(map[string]sentinel.QueryEntryResponse, len(qr.Feed.Entries))
+	ids := make([]string, 0, len(qr.Feed.Entries))
+	for _, e := range qr.Feed.Entries {
+		id := entryID(e)
+		if _, ok := byID[id]; ok || id == "" {
+			continue
+		}
+		byID[id] = e
+		ids = append(ids, id)
+	}
+	unseen, err := s.store.Unseen(w.Name, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error on read seen products: %s", err)
+	}
+	s.logger.Info("watch run", slog.String("watch", w.Name), slog.Int("found", len(ids)), slog.Int("new", len(unseen)))
+	if len(unseen) == 0 {
+		return nil, nil
+	}
+
+	entries := make([]sentinel.QueryEntryResponse, len(unseen))
+	for i, id := range unseen {
+		entries[i] = byID[id]
+	}
+	for i, h := range s.handlers {
+		key := fmt.Sprintf("%s#%d", w.Name, i)
+		pending, err := s.store.Unseen(key, unseen)
+		if err != nil {
+			return entries, fmt.Errorf("error on read seen products: %s", err)
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		handled := make([]sentinel.QueryEntryResponse, len(pending))
+		for j, id := range pending {
+			handled[j] = byID[id]
+		}
+		if err = h(ctx, w, handled); err != nil {
+			return entries, fmt.Errorf("error on handle new products: %s", err)
+		}
+		if err = s.store.MarkSeen(key, pending); err != nil {
+			return entries, fmt.Errorf("error on save seen products: %s", err)
+		}
+	}
+	if err = s.store.MarkSeen(w.Name, unseen); err != nil {
+		return entries, fmt.Errorf("error on save seen products: %s", err)
+	}
+	return entries, nil
+}
+
+func entryID(e sentinel.QueryEntryResponse) string {
+	if e.UUID != "" {
+		return e.UUID
+	}
+	return e.ID
+}
+
+// EnqueueHandler adds download jobs of new products to q. Watch Dst is used as destination,
+// defaultDst if it is empty
+func EnqueueHandler(q *queue.Queue, defaultDst string) Handler {
+	return func(ctx context.Context, w Watch, entries []sentinel.QueryEntryResponse) error {
+		dst := w.Dst
+		if dst == "" {
+			dst = defaultDst
+		}
+		for _, e := range entries {
+			if _, err := q.Add(entryID(e), dst, e.Identifier, w.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/queue"
+)
+
+func TestSchedule(t *testing.T) {
+	from := time.Date(2022, 1, 31, 22, 59, 30, 0, time.UTC) // Monday
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"0 */2 * * *", time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"30 6 1,15 * *", time.Date(2022, 2, 1, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * 5", time.Date(2022, 2, 4, 12, 0, 0, 0, time.UTC)}, // 13th or Friday
+		{"0 0 * * 7", time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 1, 31, 23, 0, 0, 0, time.UTC)},
+		{"@every 2h", from.Add(2 * time.Hour)},
+		{"@every 1d", from.Add(24 * time.Hour)},
+	}
+	for _, c := range cases {
+		s, err := ParseSchedule(c.expr)
+		if err != nil {
+			t.Errorf("%s: error should be nil, but is %s", c.expr, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(c.next) {
+			t.Errorf("%s: next should be %s, but is %s", c.expr, c.next, next)
+		}
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every 10s"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("%q: err is nil but should not be", expr)
+		}
+	}
+}
+
+func TestLoadWatches(t *testing.T) {
+	watches, err := LoadWatches("testdata/watches.yaml")
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(watches) != 2 {
+		t.Fatalf("should be 2 watches, but got %d", len(watches))
+	}
+	now := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	params := watches[0].Parameters(now)
+	if !params.BeginDate.Equal(now.Add(-72*time.Hour)) || params.CloudCoverPercentageMax != 30 || len(params.TileIDs) != 2 {
+		t.Errorf("unexpected parameters %+v", params)
+	}
+	if params.Platforms[0] != sentinel.PlanformSentinel2 {
+		t.Errorf("unexpected platform %s", params.Platforms[0])
+	}
+	if watches[1].Webhook == "" || watches[1].Parameters(now).Footprint == "" {
+		t.Errorf("unexpected watch %+v", watches[1])
+	}
+
+	// the same watches as JSON
+	bs, err := json.Marshal(watchFile{Watches: watches})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	filePath := filepath.Join(t.TempDir(), "watches.json")
+	os.WriteFile(filePath, bs, 0o644)
+	if watches, err = LoadWatches(filePath); err != nil || time.Duration(watches[0].Lookback) != 72*time.Hour {
+		t.Errorf("JSON watches should be loaded, got %v %+v", err, watches)
+	}
+}
+
+type testSearcher struct {
+	ids []string
+}
+
+func (s *testSearcher) Query(params sentinel.SearchParameters) (sentinel.QueryResponse, error) {
+	var qr sentinel.QueryResponse
+	for _, id := range s.ids {
+		qr.Feed.Entries = append(qr.Feed.Entries, sentinel.QueryEntryResponse{UUID: id, Identifier: "P_" + id})
+	}
+	return qr, nil
+}
+
+func TestRunWatch(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(filepath.Join(dir, "seen.json"), 0)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	q, err := queue.Open(filepath.Join(dir, "queue.json"))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	handled := make([]string, 0)
+	recording := func(ctx context.Context, w Watch, entries []sentinel.QueryEntryResponse) error {
+		for _, e := range entries {
+			handled = append(handled, e.UUID)
+		}
+		return nil
+	}
+	isFailing := true
+	failing := func(ctx context.Context, w Watch, entries []sentinel.QueryEntryResponse) error {
+		if isFailing {
+			return errors.New("downstream is down")
+		}
+		return nil
+	}
+	searcher := &testSearcher{ids: []string{"a", "b"}}
+	w := Watch{Name: "watch", Schedule: "@hourly", Dst: "/data"}
+	s, err := New(searcher, store, []Watch{w}, WithHandler(EnqueueHandler(q, "/tmp")), WithHandler(recording), WithHandler(failing))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	if _, err = s.RunWatch(context.Background(), w); err == nil {
+		t.Fatalf("err is nil but should not be")
+	}
+	isFailing = false
+	entries, err := s.RunWatch(context.Background(), w)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("products should be handled after failure, got %d %v", len(entries), err)
+	}
+
+	searcher.ids = []string{"c", "b", "a"}
+	entries, err = s.RunWatch(context.Background(), w)
+	if err != nil || len(entries) != 1 || entries[0].UUID != "c" {
+		t.Fatalf("only new product should be handled, got %+v %v", entries, err)
+	}
+
+	// store is persistent
+	if store, err = OpenFileStore(filepath.Join(dir, "seen.json"), 0); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if unseen, _ := store.Unseen("watch", []string{"a", "b", "c", "d"}); len(unseen) != 1 || unseen[0] != "d" {
+		t.Errorf("unexpected unseen products %v", unseen)
+	}
+
+	jobs := q.List()
+	if len(jobs) != 3 || jobs[2].ProductID != "c" || jobs[2].Dst != "/data" || jobs[2].Source != "watch" {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+	// handlers succeeded before the failure do not get products again
+	if len(handled) != 3 || handled[2] != "c" {
+		t.Errorf("unexpected handled products %v", handled)
+	}
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SeenStore remembers product IDs already handled by each watch
+type SeenStore interface {
+	// Unseen returns ids not seen by the watch yet, preserving order
+	Unseen(watch string, ids []string) ([]string, error)
+	// MarkSeen remembers ids as seen by the watch
+	MarkSeen(watch string, ids []string) error
+}
+
+// FileStore is a SeenStore kept in a JSON file. IDs seen longer than retention ago are dropped,
+// retention should exceed watches lookback
+type FileStore struct {
+	filePath  string
+	retention time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]map[string]time.Time // watch -> id -> first seen
+}
+
+// OpenFileStore loads store from filePath, the file is created on first change if it does not exist.
+// Zero retention keeps IDs forever
+func OpenFileStore(filePath string, retention time.Duration) (*FileStore, error) {
+	fs := &FileStore{
+		filePath:  filePath,
+		retention: retention,
+		now:       time.Now,
+		seen:      make(map[string]map[string]time.Time),
+	}
+	bs, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error on read seen store: %s", err)
+	}
+	if len(bs) > 0 {
+		if err = json.Unmarshal(bs, &fs.seen); err != nil {
+			return nil, fmt.Errorf("error on parse seen store %s: %s", filePath, err)
+		}
+	}
+	return fs, nil
+}
+
+func (fs *FileStore) Unseen(watch string, ids []string) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := fs.seen[watch][id]; !ok {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
+
+func (fs *FileStore) MarkSeen(watch string, ids []string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	now := fs.now()
+	if fs.seen[watch] == nil {
+		fs.seen[watch] = make(map[string]time.Time)
+	}
+	for _, id := range ids {
+		if _, ok := fs.seen[watch][id]; !ok {
+			fs.seen[watch][id] = now
+		}
+	}
+	if fs.retention > 0 {
+		for _, ids := range fs.seen {
+			for id, t := range ids {
+				if now.Sub(t) > fs.retention {
+					delete(ids, id)
+				}
+			}
+		}
+	}
+
+	bs, err := json.MarshalIndent(fs.seen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on marshal seen store: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(fs.filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create seen store directory: %s", err)
+	}
+	tmp := fs.filePath + ".tmp"
+	if err = os.WriteFile(tmp, bs, 0o644); err != nil {
+		return fmt.Errorf("error on write seen store: %s", err)
+	}
+	if err = os.Rename(tmp, fs.filePath); err != nil {
+		return fmt.Errorf("error on replace seen store: %s", err)
+	}
+	return nil
+}
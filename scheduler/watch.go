@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"gopkg.in/yaml.v3"
+)
+
+// Watch is a named recurring search, e.g.
+//
+//	name: s2-l2a-ukraine-east
+//	schedule: "0 */2 * * *"
+//	lookback: 3d
+//	dst: /data/s2
+//	webhook: https://processing.example.org/hooks/new-scenes
+//	search:
+//	  platforms: [Sentinel-2]
+//	  product_types: [S2MSI2A]
+//	  tile_ids: [36UYA, 36UXA]
+//	  cloud_cover_max: 30
+type Watch struct {
+	Name     string   `json:"name" yaml:"name"`
+	Schedule string   `json:"schedule" yaml:"schedule"` // cron expression, see ParseSchedule
+	Lookback Duration `json:"lookback" yaml:"lookback"` // searched sensing period before each run, 7 days by default
+	Search   Search   `json:"search" yaml:"search"`
+	Dst      string   `json:"dst,omitempty" yaml:"dst,omitempty"`         // download directory of new products
+	Webhook  string   `json:"webhook,omitempty" yaml:"webhook,omitempty"` // URL events of the watch are posted to, see notify.WatchWebhooks
+
+	schedule Schedule
+}
+
+// Search is a definition of SearchParameters with sensing period relative to the run time
+type Search struct {
+	Platforms     []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+	ProductTypes  []string `json:"product_types,omitempty" yaml:"product_types,omitempty"`
+	TileIDs       []string `json:"tile_ids,omitempty" yaml:"tile_ids,omitempty"`
+	Filenames     []string `json:"filenames,omitempty" yaml:"filenames,omitempty"`
+	Footprint     string   `json:"footprint,omitempty" yaml:"footprint,omitempty"` // WKT
+	AreaRelation  string   `json:"area_relation,omitempty" yaml:"area_relation,omitempty"`
+	CloudCoverMax int      `json:"cloud_cover_max,omitempty" yaml:"cloud_cover_max,omitempty"`
+}
+
+// Duration is time.Duration read from strings like "2h" or "3d"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+const defaultLookback = 7 * 24 * time.Hour
+
+// Parameters returns search parameters of a run at now: sensing period is [now-lookback, now]
+func (w Watch) Parameters(now time.Time) sentinel.SearchParameters {
+	lookback := time.Duration(w.Lookback)
+	if lookback <= 0 {
+		lookback = defaultLookback
+	}
+	params := sentinel.SearchParameters{
+		ProductTypes:            w.Search.ProductTypes,
+		TileIDs:                 w.Search.TileIDs,
+		Filenames:               w.Search.Filenames,
+		Footprint:               w.Search.Footprint,
+		AreaRelation:            sentinel.AreaRelation(w.Search.AreaRelation),
+		CloudCoverPercentageMax: w.Search.CloudCoverMax,
+		BeginDate:               now.Add(-lookback).UTC(),
+		// sorted by sensing time, newest entries are processed first
+		OrderBy: sentinel.OrderBy{Field: sentinel.OrderFieldBeginPosition, Order: sentinel.SortDescending},
+	}
+	for _, p := range w.Search.Platforms {
+		params.Platforms = append(params.Platforms, sentinel.Platform(p))
+	}
+	return params
+}
+
+// Validate checks watch and parses its schedule
+func (w *Watch) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("watch name is empty")
+	}
+	s, err := ParseSchedule(w.Schedule)
+	if err != nil {
+		return fmt.Errorf("watch %s: %s", w.Name, err)
+	}
+	w.schedule = s
+	return nil
+}
+
+type watchFile struct {
+	Watches []Watch `json:"watches" yaml:"watches"`
+}
+
+// LoadWatches reads watches from YAML (.yaml, .yml) or JSON file with top level "watches" list
+func LoadWatches(filePath string) ([]Watch, error) {
+	bs, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error on read watches: %s", err)
+	}
+	var wf watchFile
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bs, &wf)
+	default:
+		err = json.Unmarshal(bs, &wf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on parse watches %s: %s", filePath, err)
+	}
+	names := make(map[string]struct{}, len(wf.Watches))
+	for i := range wf.Watches {
+		if err = wf.Watches[i].Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := names[wf.Watches[i].Name]; ok {
+			return nil, fmt.Errorf("duplicate watch name %s", wf.Watches[i].Name)
+		}
+		names[wf.Watches[i].Name] = struct{}{}
+	}
+	return wf.Watches, nil
+}
@@ -1,6 +1,7 @@
 package sentinel
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/therox/go-sentinel/quota"
+	"github.com/therox/go-sentinel/telemetry"
 )
 
 func (ss sentinelSearcher) Query(params SearchParameters) (QueryResponse, error) {
@@ -78,74 +82,163 @@ func (ss sentinelSearcher) Query(params SearchParameters) (QueryResponse, error)
 		paramList = append(paramList, fmt.Sprintf("cloudcoverpercentage:[0 TO %d]", params.CloudCoverPercentageMax))
 	}
 
+	orderBy, err := validateOrderBy(params.OrderBy)
+	if err != nil {
+		return QueryResponse{}, err
+	}
+
 	//  Union of params
 	urlParams += strings.Join(paramList, " AND ")
 
 	urlParams = url.QueryEscape(urlParams)
-	urlParams += fmt.Sprintf("&format=json&rows=%d", ss.rows)
+	urlParams += fmt.Sprintf("&format=json&rows=%d&orderby=%s", ss.rows, url.QueryEscape(orderBy.String()))
 
 	return ss.doQuery(fmt.Sprintf("%s%s", ss.searchURL, urlParams))
 }
 
+// Default ordering keeps page boundaries stable: newly ingested products are
+// appended to the end of the result set instead of shifting earlier pages.
+var defaultOrderBy = OrderBy{Field: OrderFieldIngestionDate, Order: SortAscending}
+
+func validateOrderBy(o OrderBy) (OrderBy, error) {
+	if o.Field == "" {
+		if o.Order != "" {
+			return o, fmt.Errorf("sort order %s provided without field", o.Order)
+		}
+		return defaultOrderBy, nil
+	}
+	isFound := false
+	for _, f := range []OrderField{OrderFieldBeginPosition, OrderFieldEndPosition, OrderFieldIngestionDate, OrderFieldCloudCoverPercentage} {
+		if strings.EqualFold(string(o.Field), string(f)) {
+			isFound = true
+			o.Field = f
+			break
+		}
+	}
+	if !isFound {
+		return o, fmt.Errorf("incorrect order field provided: %s", o.Field)
+	}
+	switch strings.ToLower(string(o.Order)) {
+	case "":
+		o.Order = SortAscending
+	case string(SortAscending):
+		o.Order = SortAscending
+	case string(SortDescending):
+		o.Order = SortDescending
+	default:
+		return o, fmt.Errorf("incorrect sort order provided: %s", o.Order)
+	}
+	return o, nil
+}
+
 func (ss sentinelSearcher) doQuery(queryURL string) (QueryResponse, error) {
 
 	var qr QueryResponse
+	start := time.Now()
+	pages := 1
 
 	// ======= requesting first data page =====
-	req, err := http.NewRequest(http.MethodGet, queryURL, nil)
+	ctx, op := ss.obs.Start(context.Background(), telemetry.OpSearchPage, queryURL)
+	req, err := ss.newRequest(http.MethodGet, queryURL)
 	if err != nil {
+		op.End(0, 0, err)
 		return qr, err
 	}
-	req.SetBasicAuth(ss.user, ss.password)
+	req = req.WithContext(ctx)
 	resp, err := ss.httpClient.Do(req)
 	if err != nil {
+		op.End(0, 0, err)
 		return qr, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	defer resp.Body.Close()
+	if err = quota.ParseResponse(resp); err != nil {
+		op.End(resp.StatusCode, 0, err)
+		return qr, err
+	}
 	bs, err := io.ReadAll(resp.Body)
 	if err != nil {
+		op.End(resp.StatusCode, int64(len(bs)), err)
+		return qr, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%d:%s", resp.StatusCode, resp.Header.Get("Cause-Message"))
+		op.End(resp.StatusCode, int64(len(bs)), err)
 		return qr, err
 	}
 	qr, err = processQueryResponse(bs)
+	op.End(resp.StatusCode, int64(len(bs)), err)
 	if err != nil {
 		return qr, err
 	}
+	// Entries can still shift between pages if the hub reorders results,
+	// so duplicates are dropped by UUID
+	seen := make(map[string]struct{}, qr.Feed.TotalResults)
+	qr.Feed.Entries = dedupEntries(qr.Feed.Entries, seen)
 
 	offset := ss.rows
+	op = nil
+	attempt := 0
 	for {
-		if len(qr.Feed.Entries) < qr.Feed.TotalResults {
+		if offset < qr.Feed.TotalResults {
 
 			nextURL := queryURL + fmt.Sprintf("&start=%d", offset)
+			if op == nil {
+				ctx, op = ss.obs.Start(context.Background(), telemetry.OpSearchPage, nextURL)
+			}
 
-			req, err := http.NewRequest(http.MethodGet, nextURL, nil)
+			req, err := ss.newRequest(http.MethodGet, nextURL)
 			if err != nil {
+				op.End(0, 0, err)
 				return qr, err
 			}
-			req.SetBasicAuth(ss.user, ss.password)
+			req = req.WithContext(ctx)
 			req.Header.Add("Content-Type", "application/json")
 			resp, err := ss.httpClient.Do(req)
 			if err != nil {
+				op.End(0, 0, err)
 				return qr, err
 			}
 			bs, err := io.ReadAll(resp.Body)
 			if err != nil {
 				resp.Body.Close()
+				op.End(resp.StatusCode, int64(len(bs)), err)
 				return qr, err
 			}
 			if resp.StatusCode != 200 {
-				// repeating in case of error
 				resp.Body.Close()
+				// quota errors are already retried by limiter transport
+				if err = quota.ParseResponse(resp); err != nil {
+					op.End(resp.StatusCode, int64(len(bs)), err)
+					return qr, err
+				}
+				// repeating in case of error
+				statusErr := fmt.Errorf("%d:%s", resp.StatusCode, resp.Header.Get("Cause-Message"))
+				attempt++
+				if attempt >= ss.pageAttempts {
+					op.End(resp.StatusCode, int64(len(bs)), statusErr)
+					return qr, fmt.Errorf("error on search page at %d after %d attempts: %s", offset, attempt, statusErr)
+				}
+				op.Retry(statusErr.Error())
+				time.Sleep(ss.pageBackoff << (attempt - 1))
 				continue
 			}
+			attempt = 0
 
 			tempQR, err := processQueryResponse(bs)
+			op.End(resp.StatusCode, int64(len(bs)), err)
+			op = nil
 			if err != nil {
 				resp.Body.Close()
 				return qr, err
 			}
-			qr.Feed.Entries = append(qr.Feed.Entries, tempQR.Feed.Entries...)
+			qr.Feed.Entries = append(qr.Feed.Entries, dedupEntries(tempQR.Feed.Entries, seen)...)
 			resp.Body.Close()
+			pages++
+			if len(tempQR.Feed.Entries) == 0 {
+				// result set shrank while paginating
+				break
+			}
 
 			offset += ss.rows
 		} else {
@@ -155,9 +248,26 @@ func (ss sentinelSearcher) doQuery(queryURL string) (QueryResponse, error) {
 	}
 	// Repeat until we get TotalResults items
 
+	ss.obs.ObserveSearch(pages, len(qr.Feed.Entries), time.Since(start))
 	return qr, nil
 }
 
+func dedupEntries(entries []QueryEntryResponse, seen map[string]struct{}) []QueryEntryResponse {
+	res := entries[:0]
+	for _, e := range entries {
+		key := e.UUID
+		if key == "" {
+			key = e.ID
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, e)
+	}
+	return res
+}
+
 func processQueryResponse(bs []byte) (QueryResponse, error) {
 	var res QueryResponse
 	err := json.Unmarshal(bs, &res)
@@ -174,150 +284,16 @@ func processQueryResponse(bs []byte) (QueryResponse, error) {
 		return res, err
 	}
 	for i := range res.Feed.Entries {
-		strList, err := unpackTypedCommonData(res.Feed.Entries[i].Str)
-		if err != nil {
-			return res, err
-		}
-		for j := range strList {
-			switch strList[j].Name {
-			case "sensoroperationalmode":
-				res.Feed.Entries[i].SensorOperationalMode = strList[j].Content
-
-			case "gmlfootprint":
-				res.Feed.Entries[i].GMLFootprint = strList[j].Content
-
-			case "footprint":
-				res.Feed.Entries[i].Footprint = strList[j].Content
-
-			case "tileid":
-				res.Feed.Entries[i].TileId = strList[j].Content
-
-			case "hv_order_tileid":
-				res.Feed.Entries[i].HVOrderTileid = strList[j].Content
-
-			case "format":
-				res.Feed.Entries[i].Format = strList[j].Content
-
-			case "processingbaseline":
-				res.Feed.Entries[i].ProcessingBaseline = strList[j].Content
-
-			case "platformname":
-				res.Feed.Entries[i].PlatformName = strList[j].Content
-
-			case "filename":
-				res.Feed.Entries[i].FileName = strList[j].Content
-
-			case "instrumentname":
-				res.Feed.Entries[i].InstrumentName = strList[j].Content
-
-			case "instrumentshortname":
-				res.Feed.Entries[i].InstrumentShortName = strList[j].Content
-
-			case "size":
-				res.Feed.Entries[i].Size = strList[j].Content
-
-			case "s2datatakeid":
-				res.Feed.Entries[i].S2DataTakeID = strList[j].Content
-
-			case "producttype":
-				res.Feed.Entries[i].ProductType = strList[j].Content
-
-			case "platformidentifier":
-				res.Feed.Entries[i].PlatformIdentifier = strList[j].Content
-
-			case "level1cpdiidentifier":
-				res.Feed.Entries[i].Level1CPDIdentifier = strList[j].Content
-
-			case "orbitdirection":
-				res.Feed.Entries[i].OrbitDirection = strList[j].Content
-
-			case "platformserialidentifier":
-				res.Feed.Entries[i].PlatformSerialIdentifier = strList[j].Content
-
-			case "processinglevel":
-				res.Feed.Entries[i].ProcessingLevel = strList[j].Content
-
-			case "datastripidentifier":
-				res.Feed.Entries[i].DataStripIdentifier = strList[j].Content
-
-			case "granuleidentifier":
-				res.Feed.Entries[i].GranuleIdentifier = strList[j].Content
-
-			case "identifier":
-				res.Feed.Entries[i].Identifier = strList[j].Content
-
-			case "uuid":
-				res.Feed.Entries[i].UUID = strList[j].Content
-
-			}
-		}
-
-		intList, err := unpackTypedCommonData(res.Feed.Entries[i].Int)
-		if err != nil {
-			return res, err
-		}
-		for j := range intList {
-			switch intList[j].Name {
-			case "orbitnumber":
-				res.Feed.Entries[i].OrbitNumber, _ = strconv.Atoi(intList[j].Content)
-
-			case "relativeorbitnumber":
-				res.Feed.Entries[i].RelativeOrbitNumber, _ = strconv.Atoi(intList[j].Content)
-
-			}
-		}
-
-		doubleList, err := unpackTypedCommonData(res.Feed.Entries[i].Double)
-		if err != nil {
-			return res, err
-		}
-		for j := range doubleList {
-			switch doubleList[j].Name {
-			case "cloudcoverpercentage":
-				res.Feed.Entries[i].CloudCoverPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-
-			case "illuminationazimuthangle":
-				res.Feed.Entries[i].IlluminationAzimuthAngle, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "illuminationzenithangle":
-				res.Feed.Entries[i].IlluminationZenithAngle, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "vegetationpercentage":
-				res.Feed.Entries[i].VegetationPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "notvegetatedpercentage":
-				res.Feed.Entries[i].NotVegetatedPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "waterpercentage":
-				res.Feed.Entries[i].WaterPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "unclassifiedpercentage":
-				res.Feed.Entries[i].UnclassifiedPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "mediumprobacloudspercentage":
-				res.Feed.Entries[i].MediumProbaCloudsPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "highprobacloudspercentage":
-				res.Feed.Entries[i].HighProbaCloudsPercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-			case "snowicepercentage":
-				res.Feed.Entries[i].SnowIcePercentage, _ = strconv.ParseFloat(doubleList[j].Content, 64)
-
-			}
-		}
-
-		dateList, err := unpackTypedCommonData(res.Feed.Entries[i].Date)
-		if err != nil {
-			return res, err
-		}
-		for j := range dateList {
-			switch dateList[j].Name {
-			case "datatakesensingstart":
-				res.Feed.Entries[i].DataTakeSensingStart, err = time.Parse(time.RFC3339, dateList[j].Content)
-			case "generationdate":
-				res.Feed.Entries[i].GenerationDate, err = time.Parse(time.RFC3339, dateList[j].Content)
-			case "beginposition":
-				res.Feed.Entries[i].BeginPosition, err = time.Parse(time.RFC3339, dateList[j].Content)
-			case "endposition":
-				res.Feed.Entries[i].EndPosition, err = time.Parse(time.RFC3339, dateList[j].Content)
-			case "ingestiondate":
-				res.Feed.Entries[i].IngestionDate, err = time.Parse(time.RFC3339, dateList[j].Content)
-			}
+		for _, raw := range []json.RawMessage{res.Feed.Entries[i].Str, res.Feed.Entries[i].Int, res.Feed.Entries[i].Double, res.Feed.Entries[i].Date} {
+			list, err := unpackTypedCommonData(raw)
 			if err != nil {
 				return res, err
 			}
+			for j := range list {
+				if err = res.Feed.Entries[i].SetAttribute(list[j].Name, list[j].Content); err != nil {
+					return res, err
+				}
+			}
 		}
 	}
 	return res, nil
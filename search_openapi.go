@@ -2,6 +2,7 @@ package sentinel
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,6 +16,22 @@ const (
 	AreaRelationIsWithin   AreaRelation = "IsWithin"
 )
 
+type OrderField string
+
+type SortOrder string
+
+const (
+	OrderFieldBeginPosition        OrderField = "beginposition"
+	OrderFieldEndPosition          OrderField = "endposition"
+	OrderFieldIngestionDate        OrderField = "ingestiondate"
+	OrderFieldCloudCoverPercentage OrderField = "cloudcoverpercentage"
+)
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
 const (
 	PlanformSentinel1          Platform = "Sentinel-1"
 	PlanformSentinel2          Platform = "Sentinel-2"
@@ -31,7 +48,22 @@ type SearchParameters struct {
 	EndDate                 *time.Time // Ingestion date to, NOW if not set
 	ProductTypes            []string
 	Filenames               []string
-	CloudCoverPercentageMax int // [0 TO 100]
+	CloudCoverPercentageMax int     // [0 TO 100]
+	OrderBy                 OrderBy // ingestiondate asc if not set
+}
+
+// OrderBy defines server-side ordering of search results
+type OrderBy struct {
+	Field OrderField
+	Order SortOrder // asc if not set
+}
+
+func (o OrderBy) String() string {
+	order := o.Order
+	if order == "" {
+		order = SortAscending
+	}
+	return fmt.Sprintf("%s %s", o.Field, order)
 }
 
 type TypedCommonData struct {
@@ -93,6 +125,17 @@ type QueryEntryResponse struct {
 	MediumProbaCloudsPercentage float64
 	HighProbaCloudsPercentage   float64
 	SnowIcePercentage           float64
+	Hub                         string // name of the hub answered with this entry, set by federated searcher
+	ContentLength               int64
+	Online                      bool
+	Checksums                   []Checksum
+	Attributes                  map[string]string // all attributes by OpenSearch name
+}
+
+// Checksum is a product checksum published by the hub
+type Checksum struct {
+	Algorithm string `json:"Algorithm"`
+	Value     string `json:"Value"`
 }
 
 type QueryResponse struct {
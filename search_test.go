@@ -0,0 +1,248 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/sentineltest"
+)
+
+func TestValidateOrderBy(t *testing.T) {
+	o, err := validateOrderBy(OrderBy{})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if o != defaultOrderBy {
+		t.Errorf("default order should be %s, but is %s", defaultOrderBy, o)
+	}
+
+	o, err = validateOrderBy(OrderBy{Field: "BeginPosition", Order: "DESC"})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if o.String() != "beginposition desc" {
+		t.Errorf("order should be 'beginposition desc', but is '%s'", o)
+	}
+
+	if _, err = validateOrderBy(OrderBy{Field: "tileid"}); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+	if _, err = validateOrderBy(OrderBy{Order: SortDescending}); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+}
+
+func testEntryJSON(uuid string) string {
+	return fmt.Sprintf(`{"id":"%[1]s","title":"P_%[1]s","str":[{"name":"uuid","content":"%[1]s"}]}`, uuid)
+}
+
+func TestQueryDedupAcrossPages(t *testing.T) {
+	// page boundaries shift by one between requests: entry "2" is served twice
+	pages := map[string][]string{
+		"0": {"1", "2"},
+		"2": {"2", "3"},
+		"4": {"4"},
+	}
+	orderBy := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orderBy = r.URL.Query().Get("orderby")
+		start := r.URL.Query().Get("start")
+		if start == "" {
+			start = "0"
+		}
+		entries := make([]string, 0)
+		for _, id := range pages[start] {
+			entries = append(entries, testEntryJSON(id))
+		}
+		fmt.Fprintf(w, `{"feed":{"opensearch:totalResults":"5","entry":[%s]}}`, strings.Join(entries, ","))
+	}))
+	defer srv.Close()
+
+	ss := sentinelSearcher{httpClient: srv.Client(), searchURL: srv.URL + "/search?q=", rows: 2}
+	res, err := ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if orderBy != "ingestiondate asc" {
+		t.Errorf("orderby should be 'ingestiondate asc', but is '%s'", orderBy)
+	}
+	if len(res.Feed.Entries) != 4 {
+		t.Fatalf("should be 4 entries, but got %d", len(res.Feed.Entries))
+	}
+	for i, e := range res.Feed.Entries {
+		if e.UUID != strconv.Itoa(i+1) {
+			t.Errorf("entry %d should have UUID %d, but has %s", i, i+1, e.UUID)
+		}
+	}
+}
+
+func TestQueryCredentials(t *testing.T) {
+	hub := sentineltest.NewHub(sentineltest.Product{UUID: "1"})
+	defer hub.Close()
+	hub.SetCredentials("user", "password")
+
+	u, _ := url.Parse(hub.URL)
+	hosts := make([]string, 0)
+	auth := credentials.ProviderFunc(func(ctx context.Context, host string) (credentials.Credentials, error) {
+		hosts = append(hosts, host)
+		return credentials.Credentials{User: "user", Password: "password"}, nil
+	})
+	ss := NewSentinelSearcher("", "", WithBaseURL(hub.URL), WithCredentials(auth))
+	if _, err := ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(hosts) != 1 || hosts[0] != u.Hostname() {
+		t.Errorf("credentials should be requested once for %s, but requested for %v", u.Hostname(), hosts)
+	}
+
+	ss = NewSentinelSearcher("", "", WithBaseURL(hub.URL), WithCredentials(credentials.Chain()))
+	if _, err := ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+}
+
+// failingPages answers requests of pages after the first one with 500 while failures last
+type failingPages struct {
+	failures int
+	requests int
+}
+
+func (f *failingPages) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests++
+	if strings.Contains(req.URL.RawQuery, "start=") && f.failures > 0 {
+		f.failures--
+		return &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestQueryHub(t *testing.T) {
+	products := make([]sentineltest.Product, 0)
+	for i := 1; i <= 5; i++ {
+		products = append(products, sentineltest.Product{
+			UUID:       strconv.Itoa(i),
+			Attributes: map[string]string{"tileid": "36UYA"},
+		})
+	}
+	hub := sentineltest.NewHub(products...)
+	defer hub.Close()
+	hub.SetCredentials("user", "password")
+
+	// last page holds a single entry which the hub encodes as an object
+	ss := NewSentinelSearcher("user", "password", WithBaseURL(hub.URL), WithPageSize(2))
+	res, err := ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if hub.Requests(sentineltest.EndpointSearch) != 3 {
+		t.Errorf("should be 3 search requests, but got %d", hub.Requests(sentineltest.EndpointSearch))
+	}
+	if res.Feed.TotalResults != 5 || len(res.Feed.Entries) != 5 {
+		t.Fatalf("should be 5 entries, but got %d of %d", len(res.Feed.Entries), res.Feed.TotalResults)
+	}
+	for i, e := range res.Feed.Entries {
+		if e.UUID != strconv.Itoa(i+1) {
+			t.Errorf("entry %d should have UUID %d, but has %s", i, i+1, e.UUID)
+		}
+		if e.TileId != "36UYA" {
+			t.Errorf("entry %d should have tile 36UYA, but has %s", i, e.TileId)
+		}
+		if e.BeginPosition.IsZero() {
+			t.Errorf("entry %d has no begin position", i)
+		}
+	}
+
+	ss = NewSentinelSearcher("user", "wrong", WithBaseURL(hub.URL))
+	if _, err = ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil || !strings.HasPrefix(err.Error(), "401:") {
+		t.Errorf("error should be status error, but is %v", err)
+	}
+
+	// failed pages are retried a limited number of times
+	rt := &failingPages{}
+	ss = NewSentinelSearcher("user", "password", WithBaseURL(hub.URL), WithPageSize(2), WithTransport(rt), WithPageRetries(3, time.Millisecond))
+	rt.failures = 2
+	if _, err = ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Errorf("error should be nil, but is %s", err)
+	}
+	rt.failures, rt.requests = 3, 0
+	if _, err = ss.Query(SearchParameters{BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil || !strings.Contains(err.Error(), "500:") {
+		t.Errorf("error should be status error, but is %v", err)
+	}
+	if rt.requests != 4 {
+		t.Errorf("should be 4 search requests, but got %d", rt.requests)
+	}
+}
+
+// Fixtures are hand-written in sentineltest.Recorder format after DHuS responses, set SENTINEL_RECORD
+// to replace them with responses recorded from the hub
+func replaySearcher(t *testing.T, fixture string, rows int) ISentinelSearcher {
+	rec, err := sentineltest.NewRecorder("testdata/fixtures/"+fixture+".json", sentineltest.ModeFromEnv())
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	t.Cleanup(func() {
+		// responses of failed test are not kept as fixtures
+		if t.Failed() {
+			return
+		}
+		if err := rec.Save(); err != nil {
+			t.Errorf("error on save fixture: %s", err)
+		}
+	})
+	user, password, _ := strings.Cut(os.Getenv("SENTINEL_CREDENTIALS"), ":")
+	return NewSentinelSearcher(user, password, WithTransport(rec), WithPageSize(rows))
+}
+
+func TestProcessQueryResponseFixtures(t *testing.T) {
+	params := SearchParameters{TileIDs: []string{"36UYA"}, BeginDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	// single entry and single int/double attributes are objects, not arrays
+	res, err := replaySearcher(t, "single_entry", 100).Query(params)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if res.Feed.TotalResults != 1 || len(res.Feed.Entries) != 1 {
+		t.Fatalf("should be 1 entry, but got %d of %d", len(res.Feed.Entries), res.Feed.TotalResults)
+	}
+	e := res.Feed.Entries[0]
+	if e.UUID != "8a1b6e3a-29c4-4f5e-9d3b-1c2e3f4a5b6c" || e.TileId != "36UYA" || e.ProductType != "S2MSI2A" {
+		t.Errorf("unexpected entry %s %s %s", e.UUID, e.TileId, e.ProductType)
+	}
+	if e.OrbitNumber != 34218 || e.CloudCoverPercentage != 12.34 {
+		t.Errorf("single int and double attributes are not parsed: %d %f", e.OrbitNumber, e.CloudCoverPercentage)
+	}
+	if !e.BeginPosition.Equal(time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC)) {
+		t.Errorf("unexpected begin position %s", e.BeginPosition)
+	}
+
+	// second page holds a single entry
+	res, err = replaySearcher(t, "multi_page", 2).Query(params)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(res.Feed.Entries) != 3 {
+		t.Fatalf("should be 3 entries, but got %d", len(res.Feed.Entries))
+	}
+	if e = res.Feed.Entries[1]; e.RelativeOrbitNumber != 21 || e.SnowIcePercentage != 41.2 {
+		t.Errorf("attribute arrays are not parsed: %d %f", e.RelativeOrbitNumber, e.SnowIcePercentage)
+	}
+
+	// no "entry" key at all
+	res, err = replaySearcher(t, "empty", 100).Query(params)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if res.Feed.TotalResults != 0 || len(res.Feed.Entries) != 0 {
+		t.Errorf("should be no entries, but got %d", len(res.Feed.Entries))
+	}
+}
@@ -0,0 +1,350 @@
+// Package sentineltest provides fake DHuS hub for hermetic tests of code using go-sentinel.
+//
+// Hub serves OpenSearch JSON search with paging, OData product metadata, attributes, $value
+// downloads with Etag and Content-Disposition, online status and quicklooks. Offline products
+// are answered with 202 as the real hub does, and failures can be injected per endpoint.
+package sentineltest
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Product is a product served by the hub
+type Product struct {
+	UUID       string
+	Identifier string
+	Content    []byte
+	Online     bool
+	Quicklook  []byte
+	BeginDate  time.Time
+	Ingestion  time.Time
+	Footprint  string            // WKT
+	Attributes map[string]string // additional OpenSearch string attributes, e.g. tileid, producttype
+}
+
+// Endpoint is a kind of hub request, used to inject failures and count requests
+type Endpoint string
+
+const (
+	EndpointSearch     Endpoint = "search"
+	EndpointMetadata   Endpoint = "metadata"
+	EndpointAttributes Endpoint = "attributes"
+	EndpointDownload   Endpoint = "download"
+	EndpointOnline     Endpoint = "online"
+	EndpointQuicklook  Endpoint = "quicklook"
+)
+
+// Hub is a fake DHuS hub. Use its URL as base URL of searcher and engine.
+type Hub struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	products  []Product
+	user      string
+	password  string
+	failures  map[Endpoint][]int
+	requests  map[Endpoint]int
+	triggered map[string]bool
+}
+
+// NewHub starts fake hub serving given products. Close it when done.
+func NewHub(products ...Product) *Hub {
+	h := &Hub{
+		failures:  make(map[Endpoint][]int),
+		requests:  make(map[Endpoint]int),
+		triggered: make(map[string]bool),
+	}
+	for _, p := range products {
+		h.AddProduct(p)
+	}
+	h.Server = httptest.NewServer(http.HandlerFunc(h.serveHTTP))
+	return h
+}
+
+// AddProduct adds product to the hub, missing identifiers and dates are filled in
+func (h *Hub) AddProduct(p Product) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if p.Identifier == "" {
+		p.Identifier = "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_" + p.UUID
+	}
+	if p.BeginDate.IsZero() {
+		p.BeginDate = time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC)
+	}
+	if p.Ingestion.IsZero() {
+		p.Ingestion = p.BeginDate.Add(2 * time.Hour).Add(time.Duration(len(h.products)) * time.Second)
+	}
+	h.products = append(h.products, p)
+}
+
+// SetCredentials makes the hub require basic authentication
+func (h *Hub) SetCredentials(user string, password string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.user = user
+	h.password = password
+}
+
+// SetOnline changes product availability
+func (h *Hub) SetOnline(uuid string, isOnline bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.products {
+		if h.products[i].UUID == uuid {
+			h.products[i].Online = isOnline
+		}
+	}
+}
+
+// FailNext makes next requests to endpoint fail with given statuses, one status per request
+func (h *Hub) FailNext(endpoint Endpoint, statuses ...int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[endpoint] = append(h.failures[endpoint], statuses...)
+}
+
+// Requests returns number of requests made to endpoint, including failed ones
+func (h *Hub) Requests(endpoint Endpoint) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests[endpoint]
+}
+
+// Triggered reports whether retrieval of offline product was requested
+func (h *Hub) Triggered(uuid string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.triggered[uuid]
+}
+
+func (h *Hub) product(uuid string) (Product, bool) {
+	for _, p := range h.products {
+		if p.UUID == uuid {
+			return p, true
+		}
+	}
+	return Product{}, false
+}
+
+var reProductPath = regexp.MustCompile(`^/odata/v1/Products\('([^']+)'\)(/.*)?$`)
+
+func (h *Hub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var endpoint Endpoint
+	uuid := ""
+	suffix := ""
+	if r.URL.Path == "/search" {
+		endpoint = EndpointSearch
+	} else if m := reProductPath.FindStringSubmatch(r.URL.Path); m != nil {
+		uuid, suffix = m[1], m[2]
+		switch suffix {
+		case "":
+			endpoint = EndpointMetadata
+		case "/Attributes":
+			endpoint = EndpointAttributes
+		case "/$value":
+			endpoint = EndpointDownload
+		case "/Online/$value":
+			endpoint = EndpointOnline
+		case "/Products('Quicklook')/$value":
+			endpoint = EndpointQuicklook
+		}
+	}
+	if endpoint == "" {
+		http.NotFound(w, r)
+		return
+	}
+	h.requests[endpoint]++
+
+	if h.user != "" {
+		if user, password, ok := r.BasicAuth(); !ok || user != h.user || password != h.password {
+			w.Header().Set("Cause-Message", "Unauthorized")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if statuses := h.failures[endpoint]; len(statuses) > 0 {
+		h.failures[endpoint] = statuses[1:]
+		w.Header().Set("Cause-Message", fmt.Sprintf("injected failure %d", statuses[0]))
+		w.WriteHeader(statuses[0])
+		return
+	}
+
+	if endpoint == EndpointSearch {
+		h.serveSearch(w, r)
+		return
+	}
+	p, ok := h.product(uuid)
+	if !ok {
+		w.Header().Set("Cause-Message", "Product not found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch endpoint {
+	case EndpointMetadata:
+		h.serveMetadata(w, p)
+	case EndpointAttributes:
+		h.serveAttributes(w, p)
+	case EndpointDownload:
+		if !p.Online {
+			h.triggered[p.UUID] = true
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.zip"`, p.Identifier))
+		w.Header().Set("Content-Length", strconv.Itoa(len(p.Content)))
+		w.Header().Set("Etag", fmt.Sprintf(`"%x"`, md5.Sum(p.Content)))
+		w.Write(p.Content)
+	case EndpointOnline:
+		fmt.Fprint(w, strconv.FormatBool(p.Online))
+	case EndpointQuicklook:
+		if p.Quicklook == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(p.Quicklook)
+	}
+}
+
+type typedData struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type link struct {
+	Rel  string `json:"rel,omitempty"`
+	HREF string `json:"href"`
+}
+
+// oneOrMany mimics DHuS serialisation: single element lists are encoded as objects
+func oneOrMany[T any](list []T) interface{} {
+	if len(list) == 1 {
+		return list[0]
+	}
+	return list
+}
+
+func (h *Hub) entry(p Product) map[string]interface{} {
+	strs := []typedData{
+		{Name: "uuid", Content: p.UUID},
+		{Name: "identifier", Content: p.Identifier},
+		{Name: "filename", Content: p.Identifier + ".SAFE"},
+		{Name: "size", Content: fmt.Sprintf("%d bytes", len(p.Content))},
+	}
+	if p.Footprint != "" {
+		strs = append(strs, typedData{Name: "footprint", Content: p.Footprint})
+	}
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		strs = append(strs, typedData{Name: name, Content: p.Attributes[name]})
+	}
+	productURL := fmt.Sprintf("%s/odata/v1/Products('%s')", h.URL, p.UUID)
+	return map[string]interface{}{
+		"title":    p.Identifier,
+		"id":       p.UUID,
+		"ondemand": strconv.FormatBool(!p.Online),
+		"link": []link{
+			{HREF: productURL + "/$value"},
+			{Rel: "alternative", HREF: productURL + "/"},
+			{Rel: "icon", HREF: productURL + "/Products('Quicklook')/$value"},
+		},
+		"date": []typedData{
+			{Name: "beginposition", Content: p.BeginDate.Format(time.RFC3339Nano)},
+			{Name: "endposition", Content: p.BeginDate.Format(time.RFC3339Nano)},
+			{Name: "ingestiondate", Content: p.Ingestion.Format(time.RFC3339Nano)},
+		},
+		"str": oneOrMany(strs),
+	}
+}
+
+// serveSearch returns products ordered by ingestion date, paged with rows and start parameters
+func (h *Hub) serveSearch(w http.ResponseWriter, r *http.Request) {
+	rows, err := strconv.Atoi(r.URL.Query().Get("rows"))
+	if err != nil || rows <= 0 {
+		rows = 10
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+
+	products := make([]Product, len(h.products))
+	copy(products, h.products)
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].Ingestion.Before(products[j].Ingestion)
+	})
+
+	entries := make([]map[string]interface{}, 0)
+	for i := start; i < start+rows && i < len(products); i++ {
+		entries = append(entries, h.entry(products[i]))
+	}
+	feed := map[string]interface{}{
+		"title":                   "Sentinels Scientific Data Hub search results for: " + r.URL.Query().Get("q"),
+		"opensearch:totalResults": strconv.Itoa(len(products)),
+		"opensearch:startIndex":   strconv.Itoa(start),
+		"opensearch:itemsPerPage": strconv.Itoa(rows),
+	}
+	if len(entries) > 0 {
+		feed["entry"] = oneOrMany(entries)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"feed": feed})
+}
+
+func odataDate(t time.Time) string {
+	return fmt.Sprintf("/Date(%d)/", t.UnixMilli())
+}
+
+func (h *Hub) serveMetadata(w http.ResponseWriter, p Product) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"d": map[string]interface{}{
+		"Id":            p.UUID,
+		"Name":          p.Identifier,
+		"ContentType":   "application/octet-stream",
+		"ContentLength": strconv.Itoa(len(p.Content)),
+		"IngestionDate": odataDate(p.Ingestion),
+		"Online":        p.Online,
+		"ContentDate":   map[string]string{"Start": odataDate(p.BeginDate), "End": odataDate(p.BeginDate)},
+		"Checksum":      map[string]string{"Algorithm": "MD5", "Value": fmt.Sprintf("%X", md5.Sum(p.Content))},
+	}})
+}
+
+func (h *Hub) serveAttributes(w http.ResponseWriter, p Product) {
+	results := []map[string]string{
+		{"Name": "Filename", "Value": p.Identifier + ".SAFE"},
+		{"Name": "Identifier", "Value": p.Identifier},
+		{"Name": "Sensing start", "Value": p.BeginDate.Format(time.RFC3339Nano)},
+	}
+	if p.Footprint != "" {
+		results = append(results, map[string]string{"Name": "Footprint", "Value": p.Footprint})
+	}
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, map[string]string{"Name": name, "Value": p.Attributes[name]})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"d": map[string]interface{}{"results": results}})
+}
+
+// ProductContent returns deterministic product content of given size, handy for download tests
+func ProductContent(size int) []byte {
+	return []byte(strings.Repeat("SAFE", size/4+1)[:size])
+}
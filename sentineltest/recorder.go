@@ -0,0 +1,282 @@
+package sentineltest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode is a recorder mode
+type Mode int
+
+const (
+	// ModeReplay serves recorded interactions, requests without recording fail
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the hub and records interactions
+	ModeRecord
+)
+
+// RecordEnv is environment variable switching recorders created with ModeFromEnv to recording
+const RecordEnv = "SENTINEL_RECORD"
+
+// ModeFromEnv returns ModeRecord if SENTINEL_RECORD environment variable is set, ModeReplay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// RecordedRequest is a sanitized request
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a sanitized response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"` // body is not valid UTF-8 and is base64 encoded
+}
+
+// Interaction is a recorded request/response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Recorder is http.RoundTripper recording hub interactions to fixture file or replaying them.
+// Inject it with WithTransport option of searcher or engine.
+type Recorder struct {
+	mode          Mode
+	filePath      string
+	transport     http.RoundTripper
+	redactHeaders []string
+	redactQuery   []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// RecorderOption configures Recorder
+type RecorderOption func(*Recorder)
+
+// WithRealTransport sets transport used to reach the hub in record mode, http.DefaultTransport by default
+func WithRealTransport(rt http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithRedactedHeaders adds headers removed from fixtures
+func WithRedactedHeaders(headers ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactHeaders = append(r.redactHeaders, headers...)
+	}
+}
+
+// WithRedactedQuery adds query parameters which values are replaced in fixtures
+func WithRedactedQuery(params ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactQuery = append(r.redactQuery, params...)
+	}
+}
+
+// Credentials and session data are never written to fixtures
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Auth-Token"}
+
+var defaultRedactedQuery = []string{"token", "access_token", "password"}
+
+const redacted = "REDACTED"
+
+// NewRecorder creates recorder using fixture file at filePath. In replay mode the file is loaded,
+// in record mode it is written by Save.
+func NewRecorder(filePath string, mode Mode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		mode:          mode,
+		filePath:      filePath,
+		transport:     http.DefaultTransport,
+		redactHeaders: defaultRedactedHeaders,
+		redactQuery:   defaultRedactedQuery,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if mode == ModeReplay {
+		bs, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error on read fixture: %s", err)
+		}
+		if err = json.Unmarshal(bs, &r.interactions); err != nil {
+			return nil, fmt.Errorf("error on parse fixture %s: %s", filePath, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Interactions returns recorded or loaded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]Interaction, len(r.interactions))
+	copy(res, r.interactions)
+	return res
+}
+
+// Save writes recorded interactions to fixture file. It does nothing in replay mode
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// URLs and JSON bodies stay readable in fixtures
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.interactions); err != nil {
+		return fmt.Errorf("error on marshal fixture: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
+		return fmt.Errorf("error on create fixture directory: %s", err)
+	}
+	if err := os.WriteFile(r.filePath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error on write fixture: %s", err)
+	}
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error on read request body: %s", err)
+		}
+	}
+	recReq := RecordedRequest{
+		Method: req.Method,
+		URL:    r.sanitizeURL(req.URL),
+		Header: r.sanitizeHeader(req.Header),
+		Body:   string(reqBody),
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, recReq)
+	}
+
+	realReq := req.Clone(req.Context())
+	if reqBody != nil {
+		realReq.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := r.transport.RoundTrip(realReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error on read response body: %s", err)
+	}
+	recResp := RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     r.sanitizeHeader(resp.Header),
+	}
+	if utf8.Valid(respBody) {
+		recResp.Body = string(respBody)
+	} else {
+		recResp.Body = base64.StdEncoding.EncodeToString(respBody)
+		recResp.BodyBase64 = true
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recReq, Response: recResp})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching method, URL and body, so repeated
+// requests are answered in recorded order
+func (r *Recorder) replay(req *http.Request, recReq RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Request.Method != recReq.Method || in.Request.URL != recReq.URL || in.Request.Body != recReq.Body {
+			continue
+		}
+		r.used[i] = true
+		body := []byte(in.Response.Body)
+		if in.Response.BodyBase64 {
+			var err error
+			body, err = base64.StdEncoding.DecodeString(in.Response.Body)
+			if err != nil {
+				return nil, fmt.Errorf("error on decode recorded body: %s", err)
+			}
+		}
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s", recReq.Method, recReq.URL)
+}
+
+func (r *Recorder) sanitizeURL(u *url.URL) string {
+	su := *u
+	su.User = nil
+	q := su.Query()
+	isChanged := false
+	for _, param := range r.redactQuery {
+		for key := range q {
+			if strings.EqualFold(key, param) {
+				q.Set(key, redacted)
+				isChanged = true
+			}
+		}
+	}
+	if isChanged {
+		su.RawQuery = q.Encode()
+	}
+	return su.String()
+}
+
+func (r *Recorder) sanitizeHeader(h http.Header) http.Header {
+	res := h.Clone()
+	for _, name := range r.redactHeaders {
+		res.Del(name)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
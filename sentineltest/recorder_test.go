@@ -0,0 +1,78 @@
+package sentineltest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, c *http.Client, link string) (int, []byte) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	req.SetBasicAuth("user", "secret")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	return resp.StatusCode, bs
+}
+
+func TestRecorder(t *testing.T) {
+	content := []byte{0xff, 0xfe, 0x00, 0x01}
+	hub := NewHub(Product{UUID: "id", Content: content, Online: true})
+	defer hub.Close()
+	hub.SetCredentials("user", "secret")
+	fixture := filepath.Join(t.TempDir(), "fixtures", "hub.json")
+
+	rec, err := NewRecorder(fixture, ModeRecord, WithRealTransport(hub.Client().Transport))
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	c := &http.Client{Transport: rec}
+	get(t, c, hub.URL+"/search?q=*&rows=10&token=abc")
+	_, recorded := get(t, c, hub.URL+"/odata/v1/Products('id')/$value")
+	if !bytes.Equal(recorded, content) {
+		t.Errorf("recorder should pass response body through")
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	bs, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	for _, secret := range []string{"secret", "Authorization", "token=abc"} {
+		if strings.Contains(string(bs), secret) {
+			t.Errorf("fixture should not contain %s", secret)
+		}
+	}
+
+	hub.Close()
+	rec, err = NewRecorder(fixture, ModeReplay)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	c = &http.Client{Transport: rec}
+	status, bs := get(t, c, hub.URL+"/odata/v1/Products('id')/$value")
+	if status != http.StatusOK || !bytes.Equal(bs, content) {
+		t.Errorf("unexpected replayed response %d %v", status, bs)
+	}
+	if status, _ = get(t, c, hub.URL+"/search?q=*&rows=10&token=xyz"); status != http.StatusOK {
+		t.Errorf("status should be 200, but is %d", status)
+	}
+	if _, err = c.Get(hub.URL + "/odata/v1/Products('id')/$value"); err == nil {
+		t.Errorf("interaction should be replayed once")
+	}
+}
@@ -0,0 +1,185 @@
+package sentineltest
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// S3 is a fake S3-compatible object storage with a single bucket, serving PutObject, GetObject
+// and multipart uploads with path-style URLs. Requests must carry AWS SigV4 Authorization header
+// of the configured access key; signatures themselves are not verified.
+type S3 struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	bucket    string
+	accessKey string
+	objects   map[string][]byte
+	uploads   map[string]map[int][]byte // upload ID -> part number -> data
+	key       map[string]string         // upload ID -> key
+	nextID    int
+	completed int
+	failParts int
+}
+
+// NewS3 starts fake storage with bucket. Close it when done.
+func NewS3(bucket string, accessKey string) *S3 {
+	s := &S3{
+		bucket:    bucket,
+		accessKey: accessKey,
+		objects:   make(map[string][]byte),
+		uploads:   make(map[string]map[int][]byte),
+		key:       make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Object returns stored object
+func (s *S3) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, ok := s.objects[key]
+	return bs, ok
+}
+
+// Keys returns sorted keys of stored objects
+func (s *S3) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PendingUploads returns number of multipart uploads neither completed nor aborted
+func (s *S3) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+// CompletedUploads returns number of completed multipart uploads
+func (s *S3) CompletedUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.completed
+}
+
+// FailParts makes next n part uploads fail with 500
+func (s *S3) FailParts(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failParts = n
+}
+
+func (s *S3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/") || r.Header.Get("X-Amz-Date") == "" {
+		s.writeError(w, http.StatusForbidden, "AccessDenied", "missing or wrong signature")
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		s.writeError(w, http.StatusNotFound, "NoSuchBucket", "bucket does not exist")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.nextID++
+		id := "upload-" + strconv.Itoa(s.nextID)
+		s.uploads[id] = make(map[int][]byte)
+		s.key[id] = key
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", bucket, key, id)
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		parts, ok := s.uploads[q.Get("uploadId")]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NoSuchUpload", "upload does not exist")
+			return
+		}
+		if s.failParts > 0 {
+			s.failParts--
+			s.writeError(w, http.StatusInternalServerError, "InternalError", "injected failure")
+			return
+		}
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		parts[n] = body
+		w.Header().Set("Etag", fmt.Sprintf("\"%x\"", md5.Sum(body)))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		id := q.Get("uploadId")
+		parts, ok := s.uploads[id]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NoSuchUpload", "upload does not exist")
+			return
+		}
+		var req struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err = xml.Unmarshal(body, &req); err != nil {
+			s.writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		var data []byte
+		for i, p := range req.Parts {
+			bs, ok := parts[p.PartNumber]
+			if !ok || p.PartNumber != i+1 || p.ETag != fmt.Sprintf("\"%x\"", md5.Sum(bs)) {
+				s.writeError(w, http.StatusBadRequest, "InvalidPart", "part "+strconv.Itoa(p.PartNumber))
+				return
+			}
+			data = append(data, bs...)
+		}
+		s.objects[s.key[id]] = data
+		delete(s.uploads, id)
+		delete(s.key, id)
+		s.completed++
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key></CompleteMultipartUploadResult>", key)
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(s.uploads, q.Get("uploadId"))
+		delete(s.key, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+		w.Header().Set("Etag", fmt.Sprintf("\"%x\"", md5.Sum(body)))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		bs, ok := s.objects[key]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NoSuchKey", "key does not exist")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(bs)))
+		if r.Method == http.MethodGet {
+			w.Write(bs)
+		}
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (s *S3) writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
@@ -0,0 +1,206 @@
+package stac
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+type Catalog struct {
+	Type        string `json:"type"`
+	StacVersion string `json:"stac_version"`
+	ID          string `json:"id"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description"`
+	Links       []Link `json:"links"`
+}
+
+type Extent struct {
+	Spatial struct {
+		BBox [][]float64 `json:"bbox"`
+	} `json:"spatial"`
+	Temporal struct {
+		Interval [][]*string `json:"interval"`
+	} `json:"temporal"`
+}
+
+type Collection struct {
+	Type           string   `json:"type"`
+	StacVersion    string   `json:"stac_version"`
+	StacExtensions []string `json:"stac_extensions,omitempty"`
+	ID             string   `json:"id"`
+	Title          string   `json:"title,omitempty"`
+	Description    string   `json:"description"`
+	License        string   `json:"license"`
+	Extent         Extent   `json:"extent"`
+	Links          []Link   `json:"links"`
+}
+
+// LocalProduct is a downloaded product to be listed in static catalog
+type LocalProduct struct {
+	Entry     sentinel.QueryEntryResponse
+	Path      string // product file or SAFE directory
+	Quicklook string // optional quicklook file
+}
+
+// CollectionID returns ID of collection entry belongs to, e.g. sentinel-2-s2msi2a
+func CollectionID(e sentinel.QueryEntryResponse) string {
+	parts := make([]string, 0, 2)
+	for _, p := range []string{e.PlatformName, e.ProductType} {
+		if p != "" {
+			parts = append(parts, strings.ToLower(p))
+		}
+	}
+	if len(parts) == 0 {
+		return "sentinel"
+	}
+	return strings.Join(parts, "-")
+}
+
+func writeJSON(filePath string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("error on create directory: %s", err)
+	}
+	bs, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error on encode %s: %s", filepath.Base(filePath), err)
+	}
+	if err = os.WriteFile(filePath, bs, 0o644); err != nil {
+		return fmt.Errorf("error on write %s: %s", filePath, err)
+	}
+	return nil
+}
+
+// relHref returns slash separated path of target relative to base directory
+func relHref(baseDir string, target string) string {
+	absBase, err1 := filepath.Abs(baseDir)
+	absTarget, err2 := filepath.Abs(target)
+	if err1 == nil && err2 == nil {
+		if rel, err := filepath.Rel(absBase, absTarget); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(target)
+}
+
+// WriteCatalog writes self-contained static STAC catalog of downloaded products into dir:
+// catalog.json, collection per platform and product type, and item per product with assets
+// pointing at local files
+func WriteCatalog(dir string, id string, description string, products []LocalProduct) error {
+	catalog := Catalog{
+		Type:        "Catalog",
+		StacVersion: Version,
+		ID:          id,
+		Description: description,
+		Links:       []Link{{Rel: "root", Href: "./catalog.json", Type: "application/json"}},
+	}
+
+	collections := make(map[string]*Collection)
+	for _, lp := range products {
+		item, err := FromEntry(lp.Entry)
+		if err != nil {
+			return err
+		}
+		collectionID := CollectionID(lp.Entry)
+		item.Collection = collectionID
+		itemDir := filepath.Join(dir, collectionID, item.ID)
+
+		item.Assets[AssetProduct] = Asset{Href: relHref(itemDir, lp.Path), Title: "Product", Roles: []string{"data"}}
+		if strings.EqualFold(filepath.Ext(lp.Path), ".zip") {
+			item.Assets[AssetProduct] = Asset{Href: relHref(itemDir, lp.Path), Type: "application/zip", Title: "Zipped product", Roles: []string{"data"}}
+		}
+		if lp.Quicklook != "" {
+			item.Assets[AssetThumbnail] = Asset{Href: relHref(itemDir, lp.Quicklook), Type: "image/jpeg", Title: "Quicklook", Roles: []string{"thumbnail"}}
+		}
+		item.Links = append(item.Links,
+			Link{Rel: "root", Href: "../../catalog.json", Type: "application/json"},
+			Link{Rel: "parent", Href: "../collection.json", Type: "application/json"},
+			Link{Rel: "collection", Href: "../collection.json", Type: "application/json"},
+		)
+		if err = writeJSON(filepath.Join(itemDir, item.ID+".json"), item); err != nil {
+			return err
+		}
+
+		c, ok := collections[collectionID]
+		if !ok {
+			c = &Collection{
+				Type:        "Collection",
+				StacVersion: Version,
+				ID:          collectionID,
+				Description: fmt.Sprintf("%s %s products", lp.Entry.PlatformName, lp.Entry.ProductType),
+				License:     "proprietary",
+				Links: []Link{
+					{Rel: "root", Href: "../catalog.json", Type: "application/json"},
+					{Rel: "parent", Href: "../catalog.json", Type: "application/json"},
+					{Rel: "license", Href: "https://sentinels.copernicus.eu/documents/247904/690755/Sentinel_Data_Legal_Notice", Title: "Legal notice on the use of Copernicus Sentinel Data"},
+				},
+			}
+			c.Extent.Spatial.BBox = [][]float64{{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}}
+			c.Extent.Temporal.Interval = [][]*string{{nil, nil}}
+			collections[collectionID] = c
+		}
+		c.Links = append(c.Links, Link{Rel: "item", Href: fmt.Sprintf("./%s/%s.json", item.ID, item.ID), Type: "application/geo+json"})
+		extendExtent(&c.Extent, item)
+		for _, ext := range item.StacExtensions {
+			if !contains(c.StacExtensions, ext) {
+				c.StacExtensions = append(c.StacExtensions, ext)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(collections))
+	for id := range collections {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c := collections[id]
+		if math.IsInf(c.Extent.Spatial.BBox[0][0], 1) {
+			c.Extent.Spatial.BBox = [][]float64{{-180, -90, 180, 90}}
+		}
+		if err := writeJSON(filepath.Join(dir, id, "collection.json"), c); err != nil {
+			return err
+		}
+		catalog.Links = append(catalog.Links, Link{Rel: "child", Href: "./" + id + "/collection.json", Type: "application/json"})
+	}
+	return writeJSON(filepath.Join(dir, "catalog.json"), catalog)
+}
+
+func extendExtent(ext *Extent, item Item) {
+	if len(item.BBox) == 4 {
+		bbox := ext.Spatial.BBox[0]
+		bbox[0] = math.Min(bbox[0], item.BBox[0])
+		bbox[1] = math.Min(bbox[1], item.BBox[1])
+		bbox[2] = math.Max(bbox[2], item.BBox[2])
+		bbox[3] = math.Max(bbox[3], item.BBox[3])
+	}
+	interval := ext.Temporal.Interval[0]
+	for _, name := range []string{"datetime", "start_datetime", "end_datetime"} {
+		t, ok := item.Properties[name].(string)
+		if !ok {
+			continue
+		}
+		// formatted times are compared lexically
+		if interval[0] == nil || t < *interval[0] {
+			interval[0] = &t
+		}
+		if interval[1] == nil || t > *interval[1] {
+			interval[1] = &t
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
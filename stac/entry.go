@@ -0,0 +1,163 @@
+package stac
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/export"
+)
+
+// wktFromGeometry converts GeoJSON polygon or multipolygon to WKT
+func wktFromGeometry(g *Geometry) (string, error) {
+	if g == nil {
+		return "", nil
+	}
+	var eg export.Geometry
+	switch g.Type {
+	case "Polygon":
+		var p export.Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return "", err
+		}
+		eg.Polygons = []export.Polygon{p}
+	case "MultiPolygon":
+		eg.IsMulti = true
+		if err := json.Unmarshal(g.Coordinates, &eg.Polygons); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported geometry type %s", g.Type)
+	}
+	return eg.WKT(), nil
+}
+
+func (item Item) stringProperty(name string) string {
+	if v, ok := item.Properties[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func (item Item) numberProperty(name string) float64 {
+	switch v := item.Properties[name].(type) {
+	case float64:
+		return v
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	}
+	return 0
+}
+
+func (item Item) timeProperty(name string) (time.Time, error) {
+	v := item.stringProperty(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// platformName converts STAC platform, e.g. sentinel-2a, to the form used by the hub, e.g. Sentinel-2A
+func platformName(platform string) string {
+	if !strings.HasPrefix(platform, "sentinel") {
+		return platform
+	}
+	return "Sentinel" + strings.ToUpper(strings.TrimPrefix(platform, "sentinel"))
+}
+
+// ToEntry converts STAC Item to search result entry. Item ID becomes entry ID and identifier,
+// UUID is taken from sentinel:uuid property if present.
+func ToEntry(item Item) (sentinel.QueryEntryResponse, error) {
+	e := sentinel.QueryEntryResponse{
+		ID:         item.ID,
+		Title:      item.ID,
+		Identifier: item.ID,
+		UUID:       item.stringProperty("sentinel:uuid"),
+	}
+	var err error
+	if e.Footprint, err = wktFromGeometry(item.Geometry); err != nil {
+		return e, fmt.Errorf("error on convert geometry of %s: %s", item.ID, err)
+	}
+
+	for field, name := range map[*time.Time]string{
+		&e.BeginPosition: "start_datetime",
+		&e.EndPosition:   "end_datetime",
+		&e.IngestionDate: "created",
+	} {
+		if *field, err = item.timeProperty(name); err != nil {
+			return e, fmt.Errorf("error on parse %s of %s: %s", name, item.ID, err)
+		}
+	}
+	if e.BeginPosition.IsZero() {
+		if e.BeginPosition, err = item.timeProperty("datetime"); err != nil {
+			return e, fmt.Errorf("error on parse datetime of %s: %s", item.ID, err)
+		}
+		e.EndPosition = e.BeginPosition
+	}
+
+	e.PlatformSerialIdentifier = platformName(item.stringProperty("platform"))
+	e.PlatformName = platformName(item.stringProperty("constellation"))
+	if e.PlatformName == "" && len(e.PlatformSerialIdentifier) > 1 {
+		e.PlatformName = e.PlatformSerialIdentifier[:len(e.PlatformSerialIdentifier)-1]
+	}
+	if instruments, ok := item.Properties["instruments"].([]interface{}); ok && len(instruments) > 0 {
+		e.InstrumentShortName = strings.ToUpper(fmt.Sprint(instruments[0]))
+	}
+	e.ProductType = item.stringProperty("sentinel:product_type")
+	if e.ProductType == "" {
+		e.ProductType = item.stringProperty("sar:product_type")
+	}
+	e.TileId = item.stringProperty("sentinel:tile_id")
+	if e.TileId == "" {
+		e.TileId = strings.TrimPrefix(item.stringProperty("grid:code"), "MGRS-")
+	}
+	e.CloudCoverPercentage = item.numberProperty("eo:cloud_cover")
+	e.OrbitNumber = int(item.numberProperty("sat:absolute_orbit"))
+	e.RelativeOrbitNumber = int(item.numberProperty("sat:relative_orbit"))
+	e.OrbitDirection = strings.ToUpper(item.stringProperty("sat:orbit_state"))
+	e.SensorOperationalMode = item.stringProperty("sar:instrument_mode")
+	if _, ok := item.Properties["view:sun_elevation"]; ok {
+		e.IlluminationZenithAngle = 90 - item.numberProperty("view:sun_elevation")
+	}
+	e.IlluminationAzimuthAngle = item.numberProperty("view:sun_azimuth")
+	e.ProcessingLevel = item.stringProperty("processing:level")
+	e.ProcessingBaseline = item.stringProperty("processing:version")
+	if e.ProcessingBaseline == "" {
+		e.ProcessingBaseline = item.stringProperty("s2:processing_baseline")
+	}
+	if polarizations, ok := item.Properties["sar:polarizations"].([]interface{}); ok {
+		list := make([]string, len(polarizations))
+		for i := range polarizations {
+			list[i] = fmt.Sprint(polarizations[i])
+		}
+		e.Attributes = map[string]string{"polarisationmode": strings.Join(list, " ")}
+	}
+
+	keys := make([]string, 0, len(item.Assets))
+	for key := range item.Assets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a := item.Assets[key]
+		rel := ""
+		switch {
+		case key == AssetProduct || key == "PRODUCT":
+		case key == AssetThumbnail || contains(a.Roles, "thumbnail"):
+			rel = "icon"
+		case key == AssetMetadata:
+			rel = "alternative"
+		default:
+			continue
+		}
+		e.Link = append(e.Link, struct {
+			Rel  string `json:"rel"`
+			HREF string `json:"href"`
+		}{Rel: rel, HREF: a.Href})
+	}
+	return e, nil
+}
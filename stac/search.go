@@ -0,0 +1,431 @@
+package stac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/credentials"
+)
+
+// Collections of product types and platforms as named by CDSE and Earth Search STAC APIs
+var (
+	productTypeCollections = map[string]string{
+		"S2MSI1C": "sentinel-2-l1c",
+		"S2MSI2A": "sentinel-2-l2a",
+		"GRD":     "sentinel-1-grd",
+		"SLC":     "sentinel-1-slc",
+	}
+	platformCollections = map[sentinel.Platform][]string{
+		sentinel.PlanformSentinel1: {"sentinel-1-grd", "sentinel-1-slc"},
+		sentinel.PlanformSentinel2: {"sentinel-2-l1c", "sentinel-2-l2a"},
+	}
+	orderFields = map[sentinel.OrderField]string{
+		sentinel.OrderFieldBeginPosition:        "properties.datetime",
+		sentinel.OrderFieldEndPosition:          "properties.end_datetime",
+		sentinel.OrderFieldIngestionDate:        "properties.created",
+		sentinel.OrderFieldCloudCoverPercentage: "properties.eo:cloud_cover",
+	}
+)
+
+type searcher struct {
+	searchURL   string
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	limit       int
+	userAgent   string
+	user        string
+	password    string
+	auth        credentials.Provider
+	collections []string
+	tileField   string
+	tilePrefix  string
+}
+
+// SearcherOption configures searcher created by NewSearcher
+type SearcherOption func(*searcher)
+
+// WithHTTPClient sets http client used for search requests
+func WithHTTPClient(c *http.Client) SearcherOption {
+	return func(s *searcher) {
+		if c != nil {
+			s.httpClient = c
+		}
+	}
+}
+
+// WithTransport sets round tripper of http client. Client provided with WithHTTPClient is not modified
+func WithTransport(rt http.RoundTripper) SearcherOption {
+	return func(s *searcher) {
+		s.transport = rt
+	}
+}
+
+// WithPageSize sets number of items requested per page. Non-positive values are ignored
+func WithPageSize(limit int) SearcherOption {
+	return func(s *searcher) {
+		if limit > 0 {
+			s.limit = limit
+		}
+	}
+}
+
+// WithUserAgent sets User-Agent header of search requests
+func WithUserAgent(userAgent string) SearcherOption {
+	return func(s *searcher) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithBasicAuth sets credentials for APIs requiring authentication
+func WithBasicAuth(user string, password string) SearcherOption {
+	return func(s *searcher) {
+		s.user = user
+		s.password = password
+	}
+}
+
+// WithCredentials makes searcher ask p for credentials of the API host before each request
+func WithCredentials(p credentials.Provider) SearcherOption {
+	return func(s *searcher) {
+		s.auth = p
+	}
+}
+
+// WithCollections sets collections to search in, instead of ones derived from platforms. Product types
+// given in search parameters narrow them to collections of these types
+func WithCollections(collections ...string) SearcherOption {
+	return func(s *searcher) {
+		s.collections = collections
+	}
+}
+
+// WithTileField sets item property holding MGRS tile and prefix of its values,
+// grid:code and MGRS- by default
+func WithTileField(field string, prefix string) SearcherOption {
+	return func(s *searcher) {
+		s.tileField = field
+		s.tilePrefix = prefix
+	}
+}
+
+// NewSearcher returns searcher querying STAC API, apiURL is the API root, e.g.
+// https://catalogue.dataspace.copernicus.eu/stac. Requests are sent to <apiURL>/search
+func NewSearcher(apiURL string, opts ...SearcherOption) sentinel.ISentinelSearcher {
+	s := searcher{
+		searchURL:  strings.TrimRight(apiURL, "/") + "/search",
+		httpClient: &http.Client{},
+		limit:      100,
+		tileField:  "grid:code",
+		tilePrefix: "MGRS-",
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.transport != nil || s.auth != nil {
+		c := *s.httpClient
+		if s.transport != nil {
+			c.Transport = s.transport
+		}
+		c.Transport = credentials.Transport(s.auth, c.Transport)
+		s.httpClient = &c
+	}
+	return s
+}
+
+type searchBody map[string]interface{}
+
+func property(name string) map[string]string {
+	return map[string]string{"property": name}
+}
+
+func or(args []interface{}) interface{} {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return map[string]interface{}{"op": "or", "args": args}
+}
+
+// searchBody translates search parameters to STAC API search request with CQL2 filter
+func (s searcher) searchBody(params sentinel.SearchParameters) (searchBody, error) {
+	body := searchBody{"limit": s.limit}
+
+	// parameters which can not be expressed are errors, dropping them would widen the search
+	var collections []string
+	seen := make(map[string]struct{})
+	add := func(c string) {
+		if _, ok := seen[c]; !ok {
+			seen[c] = struct{}{}
+			collections = append(collections, c)
+		}
+	}
+	isPlatformCollections := false
+	switch {
+	case len(params.ProductTypes) > 0:
+		for _, pt := range params.ProductTypes {
+			c, ok := productTypeCollections[strings.ToUpper(pt)]
+			if !ok {
+				return nil, fmt.Errorf("product type %s has no known STAC collection, set collections with WithCollections", pt)
+			}
+			if len(s.collections) == 0 || slices.Contains(s.collections, c) {
+				add(c)
+			}
+		}
+		if len(collections) == 0 {
+			return nil, fmt.Errorf("product types %s are not in collections %s", strings.Join(params.ProductTypes, ", "), strings.Join(s.collections, ", "))
+		}
+	case len(s.collections) > 0:
+		collections = s.collections
+	case len(params.Platforms) > 0:
+		for _, p := range params.Platforms {
+			cs, ok := platformCollections[p]
+			if !ok {
+				return nil, fmt.Errorf("platform %s has no known STAC collection, set collections with WithCollections", p)
+			}
+			for _, c := range cs {
+				add(c)
+			}
+		}
+		isPlatformCollections = true
+	}
+	if len(collections) > 0 {
+		body["collections"] = collections
+	}
+
+	end := ".."
+	if params.EndDate != nil {
+		end = params.EndDate.UTC().Format(time.RFC3339)
+	}
+	body["datetime"] = params.BeginDate.UTC().Format(time.RFC3339) + "/" + end
+
+	filters := make([]interface{}, 0)
+	if params.Footprint != "" {
+		geometry, _, err := geometryFromWKT(params.Footprint)
+		if err != nil {
+			return nil, fmt.Errorf("error on convert footprint: %s", err)
+		}
+		switch strings.ToLower(string(params.AreaRelation)) {
+		case "", strings.ToLower(string(sentinel.AreaRelationIntersects)):
+			body["intersects"] = geometry
+		case strings.ToLower(string(sentinel.AreaRelationContains)):
+			// search area contains product footprint
+			filters = append(filters, map[string]interface{}{"op": "s_within", "args": []interface{}{property("geometry"), geometry}})
+		case strings.ToLower(string(sentinel.AreaRelationIsWithin)):
+			filters = append(filters, map[string]interface{}{"op": "s_contains", "args": []interface{}{property("geometry"), geometry}})
+		default:
+			return nil, fmt.Errorf("incorrect AOI relation provided: %s", params.AreaRelation)
+		}
+	}
+	if params.CloudCoverPercentageMax > 0 {
+		filters = append(filters, map[string]interface{}{"op": "<=", "args": []interface{}{property("eo:cloud_cover"), params.CloudCoverPercentageMax}})
+	}
+	if len(params.TileIDs) > 0 {
+		tiles := make([]string, len(params.TileIDs))
+		for i := range params.TileIDs {
+			tiles[i] = s.tilePrefix + params.TileIDs[i]
+		}
+		filters = append(filters, map[string]interface{}{"op": "in", "args": []interface{}{property(s.tileField), tiles}})
+	}
+	if len(params.Filenames) > 0 {
+		likes := make([]interface{}, len(params.Filenames))
+		for i := range params.Filenames {
+			pattern := strings.TrimSuffix(params.Filenames[i], ".SAFE")
+			likes[i] = map[string]interface{}{"op": "like", "args": []interface{}{property("id"), strings.ReplaceAll(pattern, "*", "%")}}
+		}
+		filters = append(filters, or(likes))
+	}
+	if len(params.Platforms) > 0 && !isPlatformCollections {
+		// collections are not derived from platforms, so platforms are checked separately
+		platforms := make([]interface{}, len(params.Platforms))
+		for i := range params.Platforms {
+			platforms[i] = map[string]interface{}{"op": "=", "args": []interface{}{property("constellation"), strings.ToLower(string(params.Platforms[i]))}}
+		}
+		filters = append(filters, or(platforms))
+	}
+	switch len(filters) {
+	case 0:
+	case 1:
+		body["filter"] = filters[0]
+		body["filter-lang"] = "cql2-json"
+	default:
+		body["filter"] = map[string]interface{}{"op": "and", "args": filters}
+		body["filter-lang"] = "cql2-json"
+	}
+
+	if params.OrderBy.Field != "" {
+		field, ok := orderFields[sentinel.OrderField(strings.ToLower(string(params.OrderBy.Field)))]
+		if !ok {
+			return nil, fmt.Errorf("incorrect order field provided: %s", params.OrderBy.Field)
+		}
+		direction := strings.ToLower(string(params.OrderBy.Order))
+		if direction == "" {
+			direction = string(sentinel.SortAscending)
+		}
+		body["sortby"] = []map[string]string{{"field": field, "direction": direction}}
+	}
+	return body, nil
+}
+
+type itemCollection struct {
+	Features       []Item `json:"features"`
+	NumberMatched  *int   `json:"numberMatched"`
+	NumberReturned int    `json:"numberReturned"`
+	Context        *struct {
+		Matched *int `json:"matched"`
+	} `json:"context"`
+	Links []struct {
+		Rel    string          `json:"rel"`
+		Href   string          `json:"href"`
+		Method string          `json:"method"`
+		Body   json.RawMessage `json:"body"`
+		Merge  bool            `json:"merge"`
+	} `json:"links"`
+}
+
+func (b searchBody) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// keep CQL2 operators like <= readable
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(b); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+func (s searcher) do(method string, link string, body searchBody) (itemCollection, error) {
+	var ic itemCollection
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := body.encode()
+		if err != nil {
+			return ic, fmt.Errorf("error on encode search request: %s", err)
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequest(method, link, reqBody)
+	if err != nil {
+		return ic, fmt.Errorf("error on create request: %s", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/geo+json")
+	if s.auth != nil {
+		c, err := s.auth.Credentials(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return ic, fmt.Errorf("error on get credentials for %s: %s", req.URL.Hostname(), err)
+		}
+		req.SetBasicAuth(c.User, c.Password)
+	} else if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ic, fmt.Errorf("error on %s %s: %s", method, link, err)
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ic, fmt.Errorf("error on read response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ic, fmt.Errorf("%d:%.200s", resp.StatusCode, bs)
+	}
+	if err = json.Unmarshal(bs, &ic); err != nil {
+		return ic, fmt.Errorf("error on parse search response: %s", err)
+	}
+	return ic, nil
+}
+
+func (s searcher) Query(params sentinel.SearchParameters) (sentinel.QueryResponse, error) {
+	var qr sentinel.QueryResponse
+	body, err := s.searchBody(params)
+	if err != nil {
+		return qr, err
+	}
+
+	seen := make(map[string]struct{})
+	method, link := http.MethodPost, s.searchURL
+	for {
+		ic, err := s.do(method, link, body)
+		if err != nil {
+			return qr, err
+		}
+		if qr.Feed.TotalResults == 0 {
+			if ic.NumberMatched != nil {
+				qr.Feed.TotalResults = *ic.NumberMatched
+			} else if ic.Context != nil && ic.Context.Matched != nil {
+				qr.Feed.TotalResults = *ic.Context.Matched
+			}
+		}
+		added := 0
+		for _, item := range ic.Features {
+			if _, ok := seen[item.ID]; ok {
+				continue
+			}
+			seen[item.ID] = struct{}{}
+			added++
+			e, err := ToEntry(item)
+			if err != nil {
+				return qr, err
+			}
+			qr.Feed.Entries = append(qr.Feed.Entries, e)
+		}
+
+		isNext := false
+		for _, l := range ic.Links {
+			// page of repeated items means the server does not advance
+			if l.Rel != "next" || added == 0 {
+				continue
+			}
+			isNext = true
+			link = l.Href
+			method = strings.ToUpper(l.Method)
+			if method == "" {
+				method = http.MethodGet
+			}
+			if method == http.MethodGet {
+				body = nil
+				break
+			}
+			var nextBody searchBody
+			if len(l.Body) > 0 {
+				if err = json.Unmarshal(l.Body, &nextBody); err != nil {
+					return qr, fmt.Errorf("error on parse next link body: %s", err)
+				}
+			}
+			if l.Merge || len(l.Body) == 0 {
+				merged := searchBody{}
+				for k, v := range body {
+					merged[k] = v
+				}
+				for k, v := range nextBody {
+					merged[k] = v
+				}
+				nextBody = merged
+			}
+			body = nextBody
+			break
+		}
+		if !isNext {
+			break
+		}
+	}
+
+	if qr.Feed.TotalResults < len(qr.Feed.Entries) {
+		qr.Feed.TotalResults = len(qr.Feed.Entries)
+	}
+	qr.Feed.TotalResultsStr = fmt.Sprint(qr.Feed.TotalResults)
+	return qr, nil
+}
@@ -0,0 +1,126 @@
+package stac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+func TestSearchBody(t *testing.T) {
+	s := NewSearcher("https://stac.example.org").(searcher)
+	et := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	body, err := s.searchBody(sentinel.SearchParameters{
+		Platforms:               []sentinel.Platform{sentinel.PlanformSentinel2},
+		ProductTypes:            []string{"S2MSI2A"},
+		TileIDs:                 []string{"36UYA"},
+		BeginDate:               time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:                 &et,
+		Footprint:               "POLYGON((35 50,37 50,37 49,35 49,35 50))",
+		CloudCoverPercentageMax: 30,
+		OrderBy:                 sentinel.OrderBy{Field: sentinel.OrderFieldBeginPosition, Order: sentinel.SortDescending},
+	})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	bs, _ := body.encode()
+	expected := `{"collections":["sentinel-2-l2a"],"datetime":"2022-01-01T00:00:00Z/2022-03-01T00:00:00Z",` +
+		`"filter":{"args":[{"args":[{"property":"eo:cloud_cover"},30],"op":"<="},` +
+		`{"args":[{"property":"grid:code"},["MGRS-36UYA"]],"op":"in"},` +
+		`{"args":[{"property":"constellation"},"sentinel-2"],"op":"="}],"op":"and"},"filter-lang":"cql2-json",` +
+		`"intersects":{"type":"Polygon","coordinates":[[[35,50],[37,50],[37,49],[35,49],[35,50]]]},"limit":100,` +
+		`"sortby":[{"direction":"desc","field":"properties.datetime"}]}`
+	if string(bs) != expected {
+		t.Errorf("unexpected search body:\n%s\n%s", bs, expected)
+	}
+
+	if _, err = s.searchBody(sentinel.SearchParameters{Footprint: "POLYGON((35 50,37 50,37 49,35 50))", AreaRelation: "Touches"}); err == nil {
+		t.Errorf("err is nil but should not be")
+	}
+
+	// unknown values would widen the search if dropped
+	if _, err = s.searchBody(sentinel.SearchParameters{Platforms: []sentinel.Platform{sentinel.PlanformSentinel3}}); err == nil {
+		t.Errorf("err is nil for unknown platform but should not be")
+	}
+	if _, err = s.searchBody(sentinel.SearchParameters{ProductTypes: []string{"OCN"}}); err == nil {
+		t.Errorf("err is nil for unknown product type but should not be")
+	}
+	s1 := NewSearcher("https://stac.example.org", WithCollections("sentinel-1-grd")).(searcher)
+	if _, err = s1.searchBody(sentinel.SearchParameters{ProductTypes: []string{"S2MSI2A"}}); err == nil {
+		t.Errorf("err is nil for product type out of collections but should not be")
+	}
+
+	s = NewSearcher("https://stac.example.org", WithCollections("sentinel-2-l2a")).(searcher)
+	body, err = s.searchBody(sentinel.SearchParameters{Platforms: []sentinel.Platform{sentinel.PlanformSentinel2}})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if bs, _ = body.encode(); !strings.Contains(string(bs), `"collections":["sentinel-2-l2a"]`) || !strings.Contains(string(bs), `"constellation"`) {
+		t.Errorf("platform should be filtered in explicit collections: %s", bs)
+	}
+}
+
+func testItemJSON(id string) string {
+	return fmt.Sprintf(`{"type":"Feature","stac_version":"1.0.0","id":"%s",
+		"geometry":{"type":"Polygon","coordinates":[[[35,50],[37,50],[37,49],[35,50]]]},
+		"properties":{"datetime":"2022-01-01T08:33:41.024Z","platform":"sentinel-2a","eo:cloud_cover":12.5,"grid:code":"MGRS-36UYA","sat:relative_orbit":21},
+		"assets":{"thumbnail":{"href":"https://stac.example.org/%[1]s.jpg","roles":["thumbnail"]}}}`, id)
+}
+
+func TestQueryPaging(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["limit"] != 2.0 {
+			t.Errorf("page size is not kept on next page: %v", body)
+		}
+		if body["token"] == nil {
+			fmt.Fprintf(w, `{"type":"FeatureCollection","numberMatched":3,"features":[%s,%s],
+				"links":[{"rel":"next","href":"%s/search","method":"POST","body":{"token":"page2"},"merge":true}]}`,
+				testItemJSON("A"), testItemJSON("B"), srv.URL)
+			return
+		}
+		fmt.Fprintf(w, `{"type":"FeatureCollection","features":[%s,%s],"links":[]}`, testItemJSON("B"), testItemJSON("C"))
+	}))
+	defer srv.Close()
+
+	res, err := NewSearcher(srv.URL, WithPageSize(2)).Query(sentinel.SearchParameters{})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if res.Feed.TotalResults != 3 || len(res.Feed.Entries) != 3 {
+		t.Fatalf("should be 3 entries, but got %d of %d", len(res.Feed.Entries), res.Feed.TotalResults)
+	}
+	e := res.Feed.Entries[2]
+	if e.Identifier != "C" || e.TileId != "36UYA" || e.CloudCoverPercentage != 12.5 || e.RelativeOrbitNumber != 21 ||
+		e.PlatformSerialIdentifier != "Sentinel-2A" || e.PlatformName != "Sentinel-2" || e.QuicklookURL() != "https://stac.example.org/C.jpg" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.Footprint != "POLYGON((35 50,37 50,37 49,35 50))" {
+		t.Errorf("unexpected footprint %s", e.Footprint)
+	}
+}
+
+func TestQueryRepeatedPage(t *testing.T) {
+	var srv *httptest.Server
+	requests := 0
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"type":"FeatureCollection","features":[%s],"links":[{"rel":"next","href":"%s/search?page=2"}]}`, testItemJSON("A"), srv.URL)
+	}))
+	defer srv.Close()
+
+	res, err := NewSearcher(srv.URL).Query(sentinel.SearchParameters{})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if len(res.Feed.Entries) != 1 || requests != 2 {
+		t.Errorf("paging should stop on page without new items, got %d entries in %d requests", len(res.Feed.Entries), requests)
+	}
+}
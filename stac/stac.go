@@ -0,0 +1,205 @@
+// Package stac converts search results to STAC 1.0 Items and writes static STAC catalogs.
+package stac
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+	"github.com/therox/go-sentinel/export"
+)
+
+const Version = "1.0.0"
+
+const (
+	ExtensionEO         = "https://stac-extensions.github.io/eo/v1.1.0/schema.json"
+	ExtensionSat        = "https://stac-extensions.github.io/sat/v1.0.0/schema.json"
+	ExtensionSAR        = "https://stac-extensions.github.io/sar/v1.0.0/schema.json"
+	ExtensionView       = "https://stac-extensions.github.io/view/v1.0.0/schema.json"
+	ExtensionProcessing = "https://stac-extensions.github.io/processing/v1.1.0/schema.json"
+)
+
+type Link struct {
+	Rel   string `json:"rel"`
+	Href  string `json:"href"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+type Asset struct {
+	Href  string   `json:"href"`
+	Type  string   `json:"type,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type Item struct {
+	Type           string                 `json:"type"`
+	StacVersion    string                 `json:"stac_version"`
+	StacExtensions []string               `json:"stac_extensions,omitempty"`
+	ID             string                 `json:"id"`
+	Geometry       *Geometry              `json:"geometry"`
+	BBox           []float64              `json:"bbox,omitempty"`
+	Properties     map[string]interface{} `json:"properties"`
+	Links          []Link                 `json:"links"`
+	Assets         map[string]Asset       `json:"assets"`
+	Collection     string                 `json:"collection,omitempty"`
+}
+
+const (
+	AssetProduct   = "product"
+	AssetThumbnail = "thumbnail"
+	AssetMetadata  = "metadata"
+)
+
+// geometryFromWKT converts WKT footprint to GeoJSON geometry and its bounding box
+func geometryFromWKT(footprint string) (*Geometry, []float64, error) {
+	if footprint == "" {
+		return nil, nil, nil
+	}
+	g, err := export.ParseWKT(footprint)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &Geometry{Type: "Polygon"}
+	var coordinates interface{} = g.Polygons[0]
+	if g.IsMulti {
+		res.Type = "MultiPolygon"
+		coordinates = g.Polygons
+	}
+	if res.Coordinates, err = json.Marshal(coordinates); err != nil {
+		return nil, nil, err
+	}
+	env := g.Envelope()
+	return res, env[:], nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// FromEntry converts search result entry to STAC Item. Download, metadata and quicklook links
+// of the entry become product, metadata and thumbnail assets.
+func FromEntry(e sentinel.QueryEntryResponse) (Item, error) {
+	item := Item{
+		Type:        "Feature",
+		StacVersion: Version,
+		ID:          e.Identifier,
+		Properties:  make(map[string]interface{}),
+		Links:       make([]Link, 0),
+		Assets:      make(map[string]Asset),
+	}
+	if item.ID == "" {
+		item.ID = e.Title
+	}
+	var err error
+	if item.Geometry, item.BBox, err = geometryFromWKT(e.Footprint); err != nil {
+		return item, fmt.Errorf("error on convert footprint of %s: %s", item.ID, err)
+	}
+
+	p := item.Properties
+	extensions := make(map[string]struct{})
+	set := func(extension string, name string, value interface{}) {
+		p[name] = value
+		if extension != "" {
+			extensions[extension] = struct{}{}
+		}
+	}
+
+	if e.BeginPosition.IsZero() {
+		p["datetime"] = nil
+	} else {
+		p["datetime"] = formatTime(e.BeginPosition)
+	}
+	if !e.EndPosition.IsZero() && !e.EndPosition.Equal(e.BeginPosition) {
+		p["start_datetime"] = formatTime(e.BeginPosition)
+		p["end_datetime"] = formatTime(e.EndPosition)
+	}
+	if !e.IngestionDate.IsZero() {
+		p["created"] = formatTime(e.IngestionDate)
+	}
+	if e.PlatformSerialIdentifier != "" {
+		p["platform"] = strings.ToLower(e.PlatformSerialIdentifier)
+	}
+	if e.PlatformName != "" {
+		p["constellation"] = strings.ToLower(e.PlatformName)
+	}
+	if e.InstrumentShortName != "" {
+		p["instruments"] = []string{strings.ToLower(e.InstrumentShortName)}
+	}
+	if e.UUID != "" {
+		p["sentinel:uuid"] = e.UUID
+	}
+	if e.ProductType != "" {
+		p["sentinel:product_type"] = e.ProductType
+	}
+	if e.TileId != "" {
+		p["sentinel:tile_id"] = e.TileId
+	}
+
+	isSAR := strings.EqualFold(e.PlatformName, string(sentinel.PlanformSentinel1))
+	if !isSAR && (e.CloudCoverPercentage > 0 || e.Attributes["cloudcoverpercentage"] != "") {
+		set(ExtensionEO, "eo:cloud_cover", e.CloudCoverPercentage)
+	}
+
+	if e.OrbitNumber > 0 {
+		set(ExtensionSat, "sat:absolute_orbit", e.OrbitNumber)
+	}
+	if e.RelativeOrbitNumber > 0 {
+		set(ExtensionSat, "sat:relative_orbit", e.RelativeOrbitNumber)
+	}
+	if e.OrbitDirection != "" {
+		set(ExtensionSat, "sat:orbit_state", strings.ToLower(e.OrbitDirection))
+	}
+
+	if isSAR {
+		set(ExtensionSAR, "sar:frequency_band", "C")
+		set(ExtensionSAR, "sar:center_frequency", 5.405)
+		if e.SensorOperationalMode != "" {
+			set(ExtensionSAR, "sar:instrument_mode", e.SensorOperationalMode)
+		}
+		if e.ProductType != "" {
+			set(ExtensionSAR, "sar:product_type", e.ProductType)
+		}
+		if pm := e.Attributes["polarisationmode"]; pm != "" {
+			set(ExtensionSAR, "sar:polarizations", strings.Fields(pm))
+		}
+	}
+
+	if e.IlluminationAzimuthAngle != 0 || e.IlluminationZenithAngle != 0 {
+		set(ExtensionView, "view:sun_azimuth", e.IlluminationAzimuthAngle)
+		set(ExtensionView, "view:sun_elevation", 90-e.IlluminationZenithAngle)
+	}
+
+	if e.ProcessingLevel != "" {
+		set(ExtensionProcessing, "processing:level", e.ProcessingLevel)
+	}
+	if e.ProcessingBaseline != "" {
+		set(ExtensionProcessing, "processing:version", e.ProcessingBaseline)
+	}
+
+	for _, ext := range []string{ExtensionEO, ExtensionSat, ExtensionSAR, ExtensionView, ExtensionProcessing} {
+		if _, ok := extensions[ext]; ok {
+			item.StacExtensions = append(item.StacExtensions, ext)
+		}
+	}
+
+	for _, l := range e.Link {
+		switch l.Rel {
+		case "":
+			item.Assets[AssetProduct] = Asset{Href: l.HREF, Type: "application/zip", Title: "Zipped product", Roles: []string{"data"}}
+		case "alternative":
+			item.Assets[AssetMetadata] = Asset{Href: l.HREF, Type: "application/xml", Title: "OData metadata", Roles: []string{"metadata"}}
+		case "icon":
+			item.Assets[AssetThumbnail] = Asset{Href: l.HREF, Type: "image/jpeg", Title: "Quicklook", Roles: []string{"thumbnail"}}
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,120 @@
+package stac
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	sentinel "github.com/therox/go-sentinel"
+)
+
+func testS2Entry() sentinel.QueryEntryResponse {
+	e := sentinel.QueryEntryResponse{
+		ID:                       "uuid-1",
+		UUID:                     "uuid-1",
+		Identifier:               "S2A_MSIL2A_20220101T083341_N0301_R021_T36UYA_20220101T104407",
+		PlatformName:             "Sentinel-2",
+		PlatformSerialIdentifier: "Sentinel-2A",
+		InstrumentShortName:      "MSI",
+		ProductType:              "S2MSI2A",
+		ProcessingLevel:          "Level-2A",
+		ProcessingBaseline:       "03.01",
+		OrbitNumber:              34000,
+		RelativeOrbitNumber:      21,
+		OrbitDirection:           "DESCENDING",
+		CloudCoverPercentage:     12.5,
+		IlluminationZenithAngle:  70,
+		IlluminationAzimuthAngle: 165,
+		BeginPosition:            time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC),
+		EndPosition:              time.Date(2022, 1, 1, 8, 33, 41, 24000000, time.UTC),
+		Footprint:                "POLYGON((35 50,37 50,37 49,35 49,35 50))",
+	}
+	e.Link = append(e.Link, struct {
+		Rel  string `json:"rel"`
+		HREF string `json:"href"`
+	}{Rel: "icon", HREF: "https://hub/odata/v1/Products('uuid-1')/Products('Quicklook')/$value"})
+	return e
+}
+
+func TestFromEntry(t *testing.T) {
+	item, err := FromEntry(testS2Entry())
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	p := item.Properties
+	if p["datetime"] != "2022-01-01T08:33:41.024Z" || p["platform"] != "sentinel-2a" || p["eo:cloud_cover"] != 12.5 ||
+		p["sat:orbit_state"] != "descending" || p["view:sun_elevation"] != 20.0 || p["processing:version"] != "03.01" {
+		t.Errorf("unexpected properties %v", p)
+	}
+	if _, ok := p["start_datetime"]; ok {
+		t.Errorf("start_datetime should not be set for instant")
+	}
+	if !reflect.DeepEqual(item.BBox, []float64{35, 49, 37, 50}) || item.Geometry.Type != "Polygon" {
+		t.Errorf("unexpected geometry %v %v", item.Geometry, item.BBox)
+	}
+	if !reflect.DeepEqual(item.StacExtensions, []string{ExtensionEO, ExtensionSat, ExtensionView, ExtensionProcessing}) {
+		t.Errorf("unexpected extensions %v", item.StacExtensions)
+	}
+	if item.Assets[AssetThumbnail].Href == "" {
+		t.Errorf("thumbnail asset is not set")
+	}
+
+	s1 := sentinel.QueryEntryResponse{
+		Identifier:            "S1A_IW_GRDH_1SDV_20220101T035512_20220101T035537_041261_04E787_5C9A",
+		PlatformName:          "Sentinel-1",
+		SensorOperationalMode: "IW",
+		ProductType:           "GRD",
+		Attributes:            map[string]string{"polarisationmode": "VV VH"},
+	}
+	item, err = FromEntry(s1)
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	if !reflect.DeepEqual(item.Properties["sar:polarizations"], []string{"VV", "VH"}) || item.Properties["sar:instrument_mode"] != "IW" {
+		t.Errorf("unexpected properties %v", item.Properties)
+	}
+	if _, ok := item.Properties["eo:cloud_cover"]; ok {
+		t.Errorf("SAR item should not have cloud cover")
+	}
+}
+
+func TestWriteCatalog(t *testing.T) {
+	root := t.TempDir()
+	productPath := filepath.Join(root, "data", "product.zip")
+	dir := filepath.Join(root, "stac")
+	err := WriteCatalog(dir, "downloads", "Downloaded products", []LocalProduct{{Entry: testS2Entry(), Path: productPath}})
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	itemID := testS2Entry().Identifier
+	bs, err := os.ReadFile(filepath.Join(dir, "sentinel-2-s2msi2a", itemID, itemID+".json"))
+	if err != nil {
+		t.Fatalf("item is not written: %s", err)
+	}
+	var item Item
+	if err = json.Unmarshal(bs, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Assets[AssetProduct].Href != "../../../data/product.zip" || item.Collection != "sentinel-2-s2msi2a" {
+		t.Errorf("unexpected item %+v", item)
+	}
+
+	bs, err = os.ReadFile(filepath.Join(dir, "sentinel-2-s2msi2a", "collection.json"))
+	if err != nil {
+		t.Fatalf("collection is not written: %s", err)
+	}
+	var c Collection
+	if err = json.Unmarshal(bs, &c); err != nil {
+		t.Fatal(err)
+	}
+	if *c.Extent.Temporal.Interval[0][0] != "2022-01-01T08:33:41.024Z" || !reflect.DeepEqual(c.Extent.Spatial.BBox[0], []float64{35, 49, 37, 50}) {
+		t.Errorf("unexpected extent %+v", c.Extent)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "catalog.json")); err != nil {
+		t.Errorf("catalog is not written: %s", err)
+	}
+}
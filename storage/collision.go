@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Collision is a policy for objects which exist already
+type Collision int
+
+const (
+	Overwrite Collision = iota // replace existing object
+	Skip                       // keep existing object, nothing is written
+	Rename                     // write to a free name with _1, _2... suffix before extension
+	Fail                       // return ErrExists
+)
+
+// ErrExists is returned by Resolve for existing object with Fail policy
+type ErrExists struct {
+	Location string
+}
+
+func (e ErrExists) Error() string {
+	return fmt.Sprintf("%s exists", e.Location)
+}
+
+// Stater is implemented by sinks able to check whether object exists
+type Stater interface {
+	// Exists returns location of object named name and whether it exists
+	Exists(ctx context.Context, name string) (string, bool, error)
+}
+
+// PolicyCreator is implemented by sinks able to apply collision policy atomically on commit
+type PolicyCreator interface {
+	CreatePolicy(ctx context.Context, name string, size int64, c Collision) (Object, error)
+}
+
+// Create returns object named name in sink. Name resolved by Resolve may be taken by the time
+// the object is committed, sinks implementing PolicyCreator apply policy c again on commit
+func Create(ctx context.Context, sink Sink, name string, size int64, c Collision) (Object, error) {
+	if pc, ok := sink.(PolicyCreator); ok {
+		return pc.CreatePolicy(ctx, name, size, c)
+	}
+	return sink.Create(ctx, name, size)
+}
+
+// Resolve applies collision policy to name in sink. It returns name to create, or location of
+// existing object if it should be kept (Skip). Sinks not implementing Stater always get name
+func Resolve(ctx context.Context, sink Sink, name string, c Collision) (string, string, error) {
+	st, ok := sink.(Stater)
+	if !ok || c == Overwrite {
+		return name, "", nil
+	}
+	location, exists, err := st.Exists(ctx, name)
+	if err != nil || !exists {
+		return name, "", err
+	}
+	switch c {
+	case Skip:
+		return "", location, nil
+	case Fail:
+		return "", "", ErrExists{Location: location}
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, exists, err = st.Exists(ctx, candidate); err != nil || !exists {
+			return candidate, "", err
+		}
+	}
+}
+
+func (l Local) Exists(ctx context.Context, name string) (string, bool, error) {
+	if err := checkName(name); err != nil {
+		return "", false, err
+	}
+	filePath := filepath.Join(l.Dir, name)
+	_, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return filePath, false, nil
+	}
+	if err != nil {
+		return filePath, false, fmt.Errorf("error on stat %s: %s", filePath, err)
+	}
+	return filePath, true, nil
+}
+
+func (s *S3) Exists(ctx context.Context, name string) (string, bool, error) {
+	if err := checkName(name); err != nil {
+		return "", false, err
+	}
+	location := s.objectURL(s.prefix+name, "")
+	_, _, err := s.do(ctx, http.MethodHead, location, nil, nil)
+	var se statusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+		return location, false, nil
+	}
+	if err != nil {
+		return location, false, fmt.Errorf("error on head object: %s", err)
+	}
+	return location, true, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/therox/go-sentinel/credentials"
+	"github.com/therox/go-sentinel/sentineltest"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "a"), 0o755)
+	os.WriteFile(filepath.Join(dir, "a", "product.zip"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "a", "product_1.zip"), nil, 0o644)
+	srv := sentineltest.NewS3("products", "AKID")
+	defer srv.Close()
+	s3 := NewS3(srv.URL, "products", WithS3Credentials(credentials.Static("AKID", "secret")))
+	o, _ := s3.Create(context.Background(), "a/product.zip", 0)
+	if _, err := o.Commit(); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	for _, sink := range []Sink{Local{Dir: dir}, s3} {
+		ctx := context.Background()
+		name, existing, err := Resolve(ctx, sink, "a/new.zip", Fail)
+		if err != nil || name != "a/new.zip" || existing != "" {
+			t.Errorf("free name should be kept, got %s %s %v", name, existing, err)
+		}
+		if name, _, _ = Resolve(ctx, sink, "a/product.zip", Overwrite); name != "a/product.zip" {
+			t.Errorf("name should be overwritten, got %s", name)
+		}
+		if _, existing, _ = Resolve(ctx, sink, "a/product.zip", Skip); existing == "" {
+			t.Errorf("existing object should be skipped")
+		}
+		var ee ErrExists
+		if _, _, err = Resolve(ctx, sink, "a/product.zip", Fail); !errors.As(err, &ee) || ee.Location != existing {
+			t.Errorf("error should be ErrExists, but is %v", err)
+		}
+	}
+
+	if name, _, _ := Resolve(context.Background(), Local{Dir: dir}, "a/product.zip", Rename); name != "a/product_2.zip" {
+		t.Errorf("name should be a/product_2.zip, but is %s", name)
+	}
+	if name, _, _ := Resolve(context.Background(), s3, "a/product.zip", Rename); name != "a/product_1.zip" {
+		t.Errorf("name should be a/product_1.zip, but is %s", name)
+	}
+}
+
+func TestCreatePolicy(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	sink := Local{Dir: dir}
+	// all downloads resolved the free name before any of them is committed
+	objects := make([]Object, 4)
+	for i, c := range []Collision{Fail, Fail, Rename, Skip} {
+		o, err := Create(ctx, sink, "product.zip", 1, c)
+		if err != nil {
+			t.Fatalf("error should be nil, but is %s", err)
+		}
+		o.Write([]byte{byte('a' + i)})
+		objects[i] = o
+	}
+
+	if location, err := objects[0].Commit(); err != nil || location != filepath.Join(dir, "product.zip") {
+		t.Fatalf("first object should be committed, got %s %v", location, err)
+	}
+	var ee ErrExists
+	if _, err := objects[1].Commit(); !errors.As(err, &ee) {
+		t.Errorf("error should be ErrExists, but is %v", err)
+	}
+	if location, err := objects[2].Commit(); err != nil || location != filepath.Join(dir, "product_1.zip") {
+		t.Errorf("object should be renamed, got %s %v", location, err)
+	}
+	if location, err := objects[3].Commit(); err != nil || location != filepath.Join(dir, "product.zip") {
+		t.Errorf("existing object should be kept, got %s %v", location, err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("should be 2 files without partial ones, but got %v", files)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(dir, "product.zip")); string(bs) != "a" {
+		t.Errorf("first object should not be replaced, got %s", bs)
+	}
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/therox/go-sentinel/credentials"
+)
+
+// MinPartSize is the smallest part of S3 multipart upload, except the last one
+const MinPartSize = 5 << 20
+
+// S3 stores objects in a bucket of S3-compatible storage (AWS, MinIO, Ceph...). Objects
+// smaller than part size are uploaded with a single PUT, bigger ones with multipart upload.
+// Path-style URLs <endpoint>/<bucket>/<key> are used
+type S3 struct {
+	endpoint   string
+	bucket     string
+	prefix     string
+	region     string
+	partSize   int
+	auth       credentials.Provider
+	token      string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// S3Option configures S3
+type S3Option func(*S3)
+
+// WithRegion sets bucket region, us-east-1 by default
+func WithRegion(region string) S3Option {
+	return func(s *S3) {
+		s.region = region
+	}
+}
+
+// WithS3Credentials sets access key (User) and secret key (Password) provider, asked for
+// the endpoint host
+func WithS3Credentials(p credentials.Provider) S3Option {
+	return func(s *S3) {
+		s.auth = p
+	}
+}
+
+// WithSessionToken sets temporary credentials session token
+func WithSessionToken(token string) S3Option {
+	return func(s *S3) {
+		s.token = token
+	}
+}
+
+// WithPrefix sets key prefix of created objects, e.g. "sentinel/"
+func WithPrefix(prefix string) S3Option {
+	return func(s *S3) {
+		s.prefix = prefix
+	}
+}
+
+// WithPartSize sets multipart upload part size, 16 MiB by default. Sizes below MinPartSize are raised to it
+func WithPartSize(size int) S3Option {
+	return func(s *S3) {
+		s.partSize = max(size, MinPartSize)
+	}
+}
+
+// WithS3HTTPClient sets http client of storage requests
+func WithS3HTTPClient(c *http.Client) S3Option {
+	return func(s *S3) {
+		s.httpClient = c
+	}
+}
+
+// NewS3 returns sink storing objects in bucket at endpoint, e.g. https://s3.eu-central-1.amazonaws.com
+// or http://localhost:9000
+func NewS3(endpoint string, bucket string, opts ...S3Option) *S3 {
+	s := &S3{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		bucket:     bucket,
+		region:     "us-east-1",
+		partSize:   16 << 20,
+		httpClient: &http.Client{},
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *S3) Create(ctx context.Context, name string, size int64) (Object, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	return &s3Object{s: s, ctx: ctx, key: s.prefix + name}, nil
+}
+
+func (s *S3) objectURL(key string, query string) string {
+	segments := strings.Split(strings.TrimLeft(key, "/"), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	link := s.endpoint + "/" + s.bucket + "/" + strings.Join(segments, "/")
+	if query != "" {
+		link += "?" + query
+	}
+	return link
+}
+
+// do sends signed request and returns response body of 2xx responses
+func (s *S3) do(ctx context.Context, method string, link string, body []byte, header http.Header) (http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on create request: %s", err)
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if s.auth != nil {
+		c, err := s.auth.Credentials(ctx, req.URL.Hostname())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error on get credentials for %s: %s", req.URL.Hostname(), err)
+		}
+		payloadHash := emptyPayloadSHA256
+		if len(body) > 0 {
+			payloadHash = hexSHA256(body)
+		}
+		signV4(req, payloadHash, c.User, c.Password, s.token, s.region, s.now())
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on %s %s: %s", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on read response body: %s", err)
+	}
+	e := statusError{StatusCode: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		xml.Unmarshal(bs, &e)
+		return nil, nil, e
+	}
+	// CompleteMultipartUpload may report failure in body of 200 response
+	if bytes.Contains(bs, []byte("<Error>")) && xml.Unmarshal(bs, &e) == nil {
+		return nil, nil, e
+	}
+	return resp.Header, bs, nil
+}
+
+// statusError is S3 error response
+type statusError struct {
+	XMLName    xml.Name `xml:"Error"`
+	StatusCode int      `xml:"-"`
+	Code       string   `xml:"Code"`
+	Message    string   `xml:"Message"`
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("%d:%s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3Object struct {
+	s        *S3
+	ctx      context.Context
+	key      string
+	buf      []byte
+	uploadID string
+	parts    []completedPart
+}
+
+func (o *s3Object) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := min(o.s.partSize-len(o.buf), len(p)-written)
+		o.buf = append(o.buf, p[written:written+chunk]...)
+		written += chunk
+		if len(o.buf) == o.s.partSize {
+			if err := o.uploadPart(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (o *s3Object) uploadPart() error {
+	if o.uploadID == "" {
+		_, bs, err := o.s.do(o.ctx, http.MethodPost, o.s.objectURL(o.key, "uploads"), nil, nil)
+		if err != nil {
+			return fmt.Errorf("error on create multipart upload: %s", err)
+		}
+		var res struct {
+			UploadID string `xml:"UploadId"`
+		}
+		if err = xml.Unmarshal(bs, &res); err != nil || res.UploadID == "" {
+			return fmt.Errorf("error on parse multipart upload: %s", bs)
+		}
+		o.uploadID = res.UploadID
+	}
+	number := len(o.parts) + 1
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {o.uploadID}}.Encode()
+	header, _, err := o.s.do(o.ctx, http.MethodPut, o.s.objectURL(o.key, query), o.buf, nil)
+	if err != nil {
+		return fmt.Errorf("error on upload part %d: %s", number, err)
+	}
+	o.parts = append(o.parts, completedPart{PartNumber: number, ETag: header.Get("Etag")})
+	o.buf = o.buf[:0]
+	return nil
+}
+
+func (o *s3Object) Commit() (string, error) {
+	location := o.s.objectURL(o.key, "")
+	if o.uploadID == "" {
+		if _, _, err := o.s.do(o.ctx, http.MethodPut, location, o.buf, nil); err != nil {
+			return "", fmt.Errorf("error on put object: %s", err)
+		}
+		return location, nil
+	}
+	if len(o.buf) > 0 {
+		if err := o.uploadPart(); err != nil {
+			o.Abort()
+			return "", err
+		}
+	}
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: o.parts})
+	if err != nil {
+		return "", fmt.Errorf("error on marshal parts: %s", err)
+	}
+	header := http.Header{"Content-Type": {"application/xml"}}
+	if _, _, err = o.s.do(o.ctx, http.MethodPost, o.s.objectURL(o.key, url.Values{"uploadId": {o.uploadID}}.Encode()), body, header); err != nil {
+		o.Abort()
+		return "", fmt.Errorf("error on complete multipart upload: %s", err)
+	}
+	return location, nil
+}
+
+func (o *s3Object) Abort() error {
+	o.buf = nil
+	if o.uploadID == "" {
+		return nil
+	}
+	// use fresh context, the download one may be canceled already
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, _, err := o.s.do(ctx, http.MethodDelete, o.s.objectURL(o.key, url.Values{"uploadId": {o.uploadID}}.Encode()), nil, nil); err != nil {
+		return fmt.Errorf("error on abort multipart upload: %s", err)
+	}
+	return nil
+}
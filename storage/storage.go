@@ -0,0 +1,157 @@
+// Package storage provides destinations products are streamed to while downloading: local
+// directories, S3-compatible object storage and plain writers. Objects are committed only after
+// the download is complete and verified, so failed downloads leave nothing behind where possible.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Sink creates objects in a destination
+type Sink interface {
+	// Create returns object named name. Size is the expected size, -1 if unknown
+	Create(ctx context.Context, name string, size int64) (Object, error)
+}
+
+// Object is an object being written. Exactly one of Commit and Abort must be called
+type Object interface {
+	io.Writer
+	// Commit completes the object and returns its location: file path, URL or name
+	Commit() (string, error)
+	// Abort discards written data
+	Abort() error
+}
+
+var nameReplacer = strings.NewReplacer("/", "_", `\`, "_", "..", "_")
+
+// SafeName returns name with path separators and .. replaced, e.g. for file names sent by the hub
+func SafeName(name string) string {
+	return nameReplacer.Replace(name)
+}
+
+// checkName rejects object names which are absolute or escape the sink root.
+// Slash separated directories are allowed
+func checkName(name string) error {
+	cleaned := path.Clean(filepath.ToSlash(name))
+	if name == "" || path.IsAbs(cleaned) || filepath.IsAbs(name) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("invalid object name %q", name)
+	}
+	return nil
+}
+
+// Local stores objects as files in Dir. Data is written to unique <name>.<random>.part file,
+// moved to name on commit
+type Local struct {
+	Dir string
+}
+
+func (l Local) Create(ctx context.Context, name string, size int64) (Object, error) {
+	return l.CreatePolicy(ctx, name, size, Overwrite)
+}
+
+// CreatePolicy returns object committed according to collision policy. Existing files are
+// detected on commit without race, files are linked instead of renamed for policies other than Overwrite
+func (l Local) CreatePolicy(ctx context.Context, name string, size int64, c Collision) (Object, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	filePath := filepath.Join(l.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return nil, fmt.Errorf("error on create directory: %s", err)
+	}
+	// concurrent downloads of the same name do not share the partial file
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.part")
+	if err != nil {
+		return nil, fmt.Errorf("error on create local file: %s", err)
+	}
+	if err = f.Chmod(0o644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("error on create local file: %s", err)
+	}
+	return &localObject{f: f, filePath: filePath, collision: c}, nil
+}
+
+type localObject struct {
+	f         *os.File
+	filePath  string
+	collision Collision
+}
+
+func (o *localObject) Write(p []byte) (int, error) {
+	return o.f.Write(p)
+}
+
+func (o *localObject) Commit() (string, error) {
+	if err := o.f.Close(); err != nil {
+		os.Remove(o.f.Name())
+		return "", fmt.Errorf("error on close local file: %s", err)
+	}
+	if o.collision == Overwrite {
+		if err := os.Rename(o.f.Name(), o.filePath); err != nil {
+			os.Remove(o.f.Name())
+			return "", fmt.Errorf("error on move local file: %s", err)
+		}
+		return o.filePath, nil
+	}
+
+	// unlike rename, link fails if the name is taken meanwhile
+	defer os.Remove(o.f.Name())
+	ext := filepath.Ext(o.filePath)
+	base := strings.TrimSuffix(o.filePath, ext)
+	filePath := o.filePath
+	for i := 1; ; i++ {
+		err := os.Link(o.f.Name(), filePath)
+		if err == nil {
+			return filePath, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error on move local file: %s", err)
+		}
+		switch o.collision {
+		case Skip:
+			return filePath, nil
+		case Fail:
+			return "", ErrExists{Location: filePath}
+		}
+		filePath = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+func (o *localObject) Abort() error {
+	o.f.Close()
+	return os.Remove(o.f.Name())
+}
+
+// Writer streams objects to w. Aborted data can not be taken back, so consumers should
+// treat output as valid only when download succeeds
+func Writer(w io.Writer) Sink {
+	return writerSink{w: w}
+}
+
+type writerSink struct {
+	w io.Writer
+}
+
+func (s writerSink) Create(ctx context.Context, name string, size int64) (Object, error) {
+	return writerObject{Writer: s.w, name: name}, nil
+}
+
+type writerObject struct {
+	io.Writer
+	name string
+}
+
+func (o writerObject) Commit() (string, error) {
+	return o.name, nil
+}
+
+func (o writerObject) Abort() error {
+	return nil
+}
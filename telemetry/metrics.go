@@ -0,0 +1,37 @@
+package telemetry
+
+import "time"
+
+// Metrics receives measurements of searchers and engines, see telemetry/prometheus for Prometheus adapter
+type Metrics interface {
+	// ObserveRequest is called after each hub request with operation name (OpSearchPage, OpDownload...),
+	// response status (0 if there was no response), transferred bytes and latency
+	ObserveRequest(op string, status int, bytes int64, latency time.Duration)
+	// ObserveSearch is called after each completed search
+	ObserveSearch(pages int, results int, latency time.Duration)
+	// ChecksumFailure is called when downloaded or verified file does not match its checksum
+	ChecksumFailure(algorithm string)
+	// RetrievalTriggered is called when the hub accepts retrieval of offline product
+	RetrievalTriggered()
+}
+
+// ObserveSearch reports completed search to Metrics, if set
+func (o Observer) ObserveSearch(pages int, results int, latency time.Duration) {
+	if o.Metrics != nil {
+		o.Metrics.ObserveSearch(pages, results, latency)
+	}
+}
+
+// ChecksumFailure reports checksum mismatch to Metrics, if set
+func (o Observer) ChecksumFailure(algorithm string) {
+	if o.Metrics != nil {
+		o.Metrics.ChecksumFailure(algorithm)
+	}
+}
+
+// RetrievalTriggered reports retrieval of offline product to Metrics, if set
+func (o Observer) RetrievalTriggered() {
+	if o.Metrics != nil {
+		o.Metrics.RetrievalTriggered()
+	}
+}
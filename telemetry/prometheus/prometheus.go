@@ -0,0 +1,149 @@
+// Package prometheus exposes searcher and engine metrics as Prometheus collectors.
+//
+//	c := prometheus.New()
+//	prom.MustRegister(c)
+//	engine := sentinel_engine.NewSentinelEngine(user, password, 0, sentinel_engine.WithMetrics(c))
+package prometheus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/therox/go-sentinel/telemetry"
+)
+
+// Collector implements telemetry.Metrics and prometheus.Collector
+type Collector struct {
+	requests         *prom.CounterVec
+	requestDuration  *prom.HistogramVec
+	bytes            *prom.CounterVec
+	throughput       *prom.HistogramVec
+	searchDuration   prom.Histogram
+	searchPages      prom.Histogram
+	searchResults    prom.Counter
+	checksumFailures *prom.CounterVec
+	retrievals       prom.Counter
+}
+
+var _ telemetry.Metrics = (*Collector)(nil)
+
+type config struct {
+	namespace   string
+	constLabels prom.Labels
+}
+
+// Option configures Collector
+type Option func(*config)
+
+// WithNamespace sets metric name prefix, "sentinel" by default
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels to all metrics, e.g. hub name when several engines are measured
+func WithConstLabels(labels map[string]string) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// New returns collector. Register it with prometheus registry and pass it to WithMetrics options
+func New(opts ...Option) *Collector {
+	cfg := config{namespace: "sentinel"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ns, labels := cfg.namespace, cfg.constLabels
+	return &Collector{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: ns, Name: "http_requests_total", ConstLabels: labels,
+			Help: "Hub requests by operation and response status, status is \"error\" if no response was received.",
+		}, []string{"op", "status"}),
+		requestDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: ns, Name: "http_request_duration_seconds", ConstLabels: labels,
+			Help:    "Hub request latency including body transfer and retries.",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900, 1800},
+		}, []string{"op"}),
+		bytes: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: ns, Name: "transferred_bytes_total", ConstLabels: labels,
+			Help: "Bytes received from hub by operation.",
+		}, []string{"op"}),
+		throughput: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: ns, Name: "download_throughput_bytes_per_second", ConstLabels: labels,
+			Help:    "Throughput of completed downloads.",
+			Buckets: prom.ExponentialBuckets(64*1024, 2, 12),
+		}, []string{"op"}),
+		searchDuration: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: ns, Name: "search_duration_seconds", ConstLabels: labels,
+			Help:    "Duration of complete searches, all pages included.",
+			Buckets: prom.ExponentialBuckets(.1, 2, 12),
+		}),
+		searchPages: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: ns, Name: "search_pages", ConstLabels: labels,
+			Help:    "Pages requested per search.",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		}),
+		searchResults: prom.NewCounter(prom.CounterOpts{
+			Namespace: ns, Name: "search_results_total", ConstLabels: labels,
+			Help: "Entries returned by searches.",
+		}),
+		checksumFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: ns, Name: "checksum_failures_total", ConstLabels: labels,
+			Help: "Downloaded or verified files not matching their checksum, by algorithm.",
+		}, []string{"algorithm"}),
+		retrievals: prom.NewCounter(prom.CounterOpts{
+			Namespace: ns, Name: "lta_retrievals_total", ConstLabels: labels,
+			Help: "Offline product retrievals triggered from long-term archive.",
+		}),
+	}
+}
+
+func (c *Collector) collectors() []prom.Collector {
+	return []prom.Collector{c.requests, c.requestDuration, c.bytes, c.throughput, c.searchDuration,
+		c.searchPages, c.searchResults, c.checksumFailures, c.retrievals}
+}
+
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, col := range c.collectors() {
+		col.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, col := range c.collectors() {
+		col.Collect(ch)
+	}
+}
+
+func (c *Collector) ObserveRequest(op string, status int, bytes int64, latency time.Duration) {
+	statusLabel := "error"
+	if status > 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	c.requests.WithLabelValues(op, statusLabel).Inc()
+	c.requestDuration.WithLabelValues(op).Observe(latency.Seconds())
+	if bytes > 0 {
+		c.bytes.WithLabelValues(op).Add(float64(bytes))
+	}
+	if strings.HasPrefix(op, telemetry.OpDownload) && status == 200 && bytes > 0 && latency > 0 {
+		c.throughput.WithLabelValues(op).Observe(float64(bytes) / latency.Seconds())
+	}
+}
+
+func (c *Collector) ObserveSearch(pages int, results int, latency time.Duration) {
+	c.searchDuration.Observe(latency.Seconds())
+	c.searchPages.Observe(float64(pages))
+	c.searchResults.Add(float64(results))
+}
+
+func (c *Collector) ChecksumFailure(algorithm string) {
+	c.checksumFailures.WithLabelValues(algorithm).Inc()
+}
+
+func (c *Collector) RetrievalTriggered() {
+	c.retrievals.Inc()
+}
@@ -0,0 +1,81 @@
+package prometheus
+
+import (
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	sentinel "github.com/therox/go-sentinel"
+	sentinel_engine "github.com/therox/go-sentinel/backend/sentinel"
+	"github.com/therox/go-sentinel/sentineltest"
+)
+
+// gather returns metric values by name and label values joined with ","
+func gather(t *testing.T, reg *prom.Registry) map[string]float64 {
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	res := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			key := f.GetName()
+			for _, l := range m.GetLabel() {
+				if l.GetName() != "hub" {
+					key += "," + l.GetValue()
+				}
+			}
+			switch {
+			case m.GetCounter() != nil:
+				res[key] = m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				res[key] = float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return res
+}
+
+func TestCollector(t *testing.T) {
+	content := sentineltest.ProductContent(4096)
+	hub := sentineltest.NewHub(
+		sentineltest.Product{UUID: "online", Content: content, Online: true},
+		sentineltest.Product{UUID: "offline", Content: content},
+	)
+	defer hub.Close()
+
+	c := New(WithConstLabels(map[string]string{"hub": "test"}))
+	reg := prom.NewRegistry()
+	reg.MustRegister(c)
+	se := sentinel_engine.NewSentinelEngine("user", "password", 0, sentinel_engine.WithBaseURL(hub.URL), sentinel_engine.WithMetrics(c))
+
+	dst := t.TempDir()
+	if _, err := se.Download("online", dst); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+	se.Download("offline", dst)
+	c.ChecksumFailure("MD5")
+
+	ss := sentinel.NewSentinelSearcher("user", "password", sentinel.WithBaseURL(hub.URL), sentinel.WithPageSize(1), sentinel.WithMetrics(c))
+	if _, err := ss.Query(sentinel.SearchParameters{}); err != nil {
+		t.Fatalf("error should be nil, but is %s", err)
+	}
+
+	metrics := gather(t, reg)
+	expected := map[string]float64{
+		"sentinel_http_requests_total,sentinel.download,200":              1,
+		"sentinel_http_requests_total,sentinel.download,202":              1,
+		"sentinel_http_requests_total,sentinel.odata,200":                 2,
+		"sentinel_transferred_bytes_total,sentinel.download":              4096,
+		"sentinel_download_throughput_bytes_per_second,sentinel.download": 1,
+		"sentinel_http_requests_total,sentinel.search.page,200":           2,
+		"sentinel_search_pages":                                           1,
+		"sentinel_search_results_total":                                   2,
+		"sentinel_lta_retrievals_total":                                   1,
+		"sentinel_checksum_failures_total,MD5":                            1,
+	}
+	for key, value := range expected {
+		if metrics[key] != value {
+			t.Errorf("%s should be %v, but is %v", key, value, metrics[key])
+		}
+	}
+}
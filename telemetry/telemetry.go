@@ -0,0 +1,181 @@
+// Package telemetry provides logging and tracing hooks of searchers and engines.
+// Both are disabled by default: zero Observer discards logs and spans.
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Tracer starts spans, e.g. an adapter to OpenTelemetry tracer
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is a traced operation
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error)
+	End()
+}
+
+type noopTracer struct{}
+
+type noopSpan struct{}
+
+func (noopTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) SetAttributes(attrs ...slog.Attr) {}
+func (noopSpan) RecordError(err error)            {}
+func (noopSpan) End()                             {}
+
+// NoopTracer returns tracer which spans do nothing
+func NoopTracer() Tracer {
+	return noopTracer{}
+}
+
+// Operation names used by searchers and engines
+const (
+	OpSearchPage        = "sentinel.search.page"
+	OpOData             = "sentinel.odata"
+	OpDownload          = "sentinel.download"
+	OpDownloadNode      = "sentinel.download.node"
+	OpDownloadQuicklook = "sentinel.download.quicklook"
+)
+
+// Observer logs, traces and measures hub requests. Nil Logger, Tracer and Metrics disable them
+type Observer struct {
+	Logger  *slog.Logger
+	Tracer  Tracer
+	Metrics Metrics
+}
+
+// Operation is a logged and traced request to the hub, including its retries
+type Operation struct {
+	name    string
+	url     string
+	start   time.Time
+	retries int
+	ctx     context.Context
+	span    Span
+	logger  *slog.Logger
+	metrics Metrics
+}
+
+// Start starts operation on link. Credentials are removed from the link before it is logged
+func (o Observer) Start(ctx context.Context, name string, link string) (context.Context, *Operation) {
+	logger := o.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	tracer := o.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	op := &Operation{
+		name:    name,
+		url:     RedactURL(link),
+		start:   time.Now(),
+		logger:  logger,
+		metrics: o.Metrics,
+	}
+	op.ctx, op.span = tracer.Start(ctx, name, slog.String("url", op.url))
+	op.ctx = context.WithValue(op.ctx, operationKey{}, op)
+	logger.DebugContext(op.ctx, "request started", slog.String("op", name), slog.String("url", op.url))
+	return op.ctx, op
+}
+
+type operationKey struct{}
+
+// OperationFromContext returns operation started with the context, nil if there is none.
+// Transports use it to record retries of requests made with the context
+func OperationFromContext(ctx context.Context) *Operation {
+	op, _ := ctx.Value(operationKey{}).(*Operation)
+	return op
+}
+
+// Retry records retry of the operation. It does nothing on nil operation
+func (op *Operation) Retry(reason string) {
+	if op == nil {
+		return
+	}
+	op.retries++
+	op.span.SetAttributes(slog.Int("retries", op.retries))
+	op.logger.WarnContext(op.ctx, "request retried",
+		slog.String("op", op.name),
+		slog.String("url", op.url),
+		slog.Int("retry", op.retries),
+		slog.String("reason", reason),
+	)
+}
+
+// Warn logs problem of the operation which does not fail it. It does nothing on nil operation
+func (op *Operation) Warn(msg string, err error) {
+	if op == nil {
+		return
+	}
+	op.span.SetAttributes(slog.String("warning", msg))
+	op.logger.WarnContext(op.ctx, msg,
+		slog.String("op", op.name),
+		slog.String("url", op.url),
+		slog.String("error", err.Error()),
+	)
+}
+
+// End finishes operation with response status, transferred bytes and error, if any.
+// Status is 0 if no response was received
+func (op *Operation) End(status int, bytes int64, err error) {
+	latency := time.Since(op.start)
+	attrs := []slog.Attr{
+		slog.String("op", op.name),
+		slog.String("url", op.url),
+		slog.Int("status", status),
+		slog.Int64("bytes", bytes),
+		slog.Duration("latency", latency),
+		slog.Int("retries", op.retries),
+	}
+	op.span.SetAttributes(attrs[2:]...)
+	if op.metrics != nil {
+		op.metrics.ObserveRequest(op.name, status, bytes, latency)
+	}
+	if err != nil {
+		op.span.RecordError(err)
+		op.logger.LogAttrs(op.ctx, slog.LevelError, "request failed", append(attrs, slog.String("error", err.Error()))...)
+	} else {
+		op.logger.LogAttrs(op.ctx, slog.LevelDebug, "request finished", attrs...)
+	}
+	op.span.End()
+}
+
+// Query parameters with secrets, compared case-insensitively
+var secretParams = []string{"token", "access_token", "password", "apikey", "api_key", "signature", "x-amz-signature", "x-amz-credential"}
+
+// RedactURL removes user info and secret query parameters from link
+func RedactURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		q := u.Query()
+		isChanged := false
+		for key := range q {
+			for _, secret := range secretParams {
+				if strings.EqualFold(key, secret) {
+					q.Set(key, "REDACTED")
+					isChanged = true
+				}
+			}
+		}
+		if isChanged {
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String()
+}